@@ -0,0 +1,387 @@
+package bifrost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DE-labtory/bifrost/pb"
+	"github.com/DE-labtory/iLogger"
+	"github.com/golang/protobuf/proto"
+)
+
+// 재전송을 모두 마칠 때까지 상대방의 ACK 를 받지 못한 경우 발생하는 에러
+var ErrAckTimeout = errors.New("ack timeout")
+
+const (
+	defaultAckTimeout    = 5 * time.Second
+	defaultAckMaxRetries = 2
+	// 재전송된 envelope 을 다시 처리하지 않도록 기억하는 ACK 결과의 수
+	defaultAckHistory = 1024
+)
+
+// AckCode 는 ACK 로 알리는 처리 결과이다. application 은 AckUnhandled 보다 큰 값을 실패 사유로 사용할 수 있다.
+type AckCode uint32
+
+const (
+	AckDelivered AckCode = iota
+	// handler 가 Message.Reject 로 처리를 거절함
+	AckRejected
+	// handler 가 등록되지 않아 처리하지 못함
+	AckUnhandled
+)
+
+// AckOpts 는 ACK 설정. 값을 지정하지 않은(zero value) field 는 기본값을 사용한다.
+type AckOpts struct {
+	// handshake 에서 ACK 를 지원한다고 알리고, 합의하면 보낸 메세지의 callback 을 상대방의 ACK 를 받은 뒤에 호출한다.
+	Enabled bool
+	// 메세지를 쓴 뒤 ACK 를 기다리는 시간. 기본값은 5초.
+	Timeout time.Duration
+	// ACK 를 받지 못한 메세지를 다시 보내는 횟수. 기본값은 2, 음수이면 다시 보내지 않는다.
+	MaxRetries int
+}
+
+// DeliveryError 는 상대방이 ACK 로 알린 처리 실패이다.
+type DeliveryError struct {
+	Code   AckCode
+	Reason string
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("delivery failed [%d]: %s", e.Code, e.Reason)
+}
+
+// deliveryAck 는 ACK 를 요청한 메세지의 처리 결과이다. handler 는 Message.Reject 로 결과를 바꾼다.
+type deliveryAck struct {
+	id     uint64
+	code   AckCode
+	reason string
+}
+
+// Reject 는 ACK 를 요청한 메세지의 처리에 실패했음을 보낸 쪽에 알린다.
+// ServeRequest 가 반환하기 전에 호출해야 하며, ACK 를 요청하지 않은 메세지이면 아무것도 하지 않는다.
+func (m *Message) Reject(code AckCode, reason string) {
+
+	if m.ack == nil {
+		return
+	}
+
+	m.ack.code = code
+	m.ack.reason = reason
+}
+
+// pendingAck 은 ACK 를 기다리는 메세지이다. 다시 보낼 수 있도록 서명하기 전의 payload 를 가지고 있다.
+type pendingAck struct {
+	protocol  string
+	payload   []byte
+	retries   int
+	timer     *time.Timer
+	onSuccess func(interface{})
+	onErr     func(error)
+}
+
+type ackState int
+
+const (
+	ackNew ackState = iota
+	// handler 가 처리 중이다. ACK 는 처리가 끝나면 보낸다.
+	ackInProgress
+	ackDone
+)
+
+// ackTracker 는 보낸 메세지의 ACK 를 기다리고, 받은 메세지의 처리 결과를 기억한다.
+type ackTracker struct {
+	sync.Mutex
+	opts    AckOpts
+	nextID  uint64
+	pending map[uint64]*pendingAck
+	closed  bool
+	// 받은 메세지의 ack ID 별 처리 결과. nil 이면 처리 중이다.
+	history map[uint64]*deliveryAck
+	order   []uint64
+}
+
+func newAckTracker(opts AckOpts) *ackTracker {
+
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultAckTimeout
+	}
+
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultAckMaxRetries
+	}
+
+	return &ackTracker{
+		opts:    opts,
+		pending: make(map[uint64]*pendingAck),
+		history: make(map[uint64]*deliveryAck),
+	}
+}
+
+// add 는 ACK 를 기다릴 메세지를 등록하고 ack ID 를 반환한다. connection 이 닫힌 뒤에는 ErrConnClosed 를 반환한다.
+func (t *ackTracker) add(protocol string, payload []byte, successCallBack func(interface{}), errCallBack func(error)) (uint64, error) {
+
+	t.Lock()
+	defer t.Unlock()
+
+	if t.closed {
+		return 0, ErrConnClosed
+	}
+
+	t.nextID++
+	t.pending[t.nextID] = &pendingAck{
+		protocol:  protocol,
+		payload:   payload,
+		onSuccess: successCallBack,
+		onErr:     errCallBack,
+	}
+
+	return t.nextID, nil
+}
+
+func (t *ackTracker) remove(id uint64) {
+
+	t.Lock()
+	defer t.Unlock()
+
+	if p, ok := t.pending[id]; ok && p.timer != nil {
+		p.timer.Stop()
+	}
+
+	delete(t.pending, id)
+}
+
+// written 은 메세지를 stream 에 쓴 뒤 ACK 를 기다리기 시작한다. 제한 시간이 지나면 onTimeout 을 호출한다.
+func (t *ackTracker) written(id uint64, onTimeout func()) {
+
+	t.Lock()
+	defer t.Unlock()
+
+	p, ok := t.pending[id]
+	if !ok {
+		return
+	}
+
+	p.timer = time.AfterFunc(t.opts.Timeout, onTimeout)
+}
+
+// retry 는 다시 보낼 수 있으면 보낼 메세지를 반환한다. 재전송 횟수를 모두 썼으면 ErrAckTimeout 으로 끝낸다.
+func (t *ackTracker) retry(id uint64) (*pendingAck, bool) {
+
+	t.Lock()
+
+	p, ok := t.pending[id]
+	if !ok {
+		t.Unlock()
+		return nil, false
+	}
+
+	if p.retries < t.opts.MaxRetries {
+		p.retries++
+		t.Unlock()
+		return p, true
+	}
+
+	t.Unlock()
+	t.resolve(id, ErrAckTimeout)
+
+	return nil, false
+}
+
+// resolve 는 ACK 를 기다리는 메세지의 callback 을 결과에 따라 호출한다. 이미 끝난 메세지이면 false 를 반환한다.
+func (t *ackTracker) resolve(id uint64, err error) bool {
+
+	t.Lock()
+
+	p, ok := t.pending[id]
+	if ok {
+		delete(t.pending, id)
+		if p.timer != nil {
+			p.timer.Stop()
+		}
+	}
+
+	t.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	if err != nil {
+		if p.onErr != nil {
+			go p.onErr(err)
+		}
+		return true
+	}
+
+	if p.onSuccess != nil {
+		go p.onSuccess("")
+	}
+
+	return true
+}
+
+// closeAll 은 ACK 를 기다리는 모든 메세지를 err 로 끝내고, 이후의 메세지는 받지 않는다.
+func (t *ackTracker) closeAll(err error) {
+
+	t.Lock()
+
+	t.closed = true
+
+	pending := t.pending
+	t.pending = make(map[uint64]*pendingAck)
+
+	t.Unlock()
+
+	for _, p := range pending {
+		if p.timer != nil {
+			p.timer.Stop()
+		}
+		if p.onErr != nil {
+			go p.onErr(err)
+		}
+	}
+}
+
+// receive 는 받은 메세지의 ack ID 를 기록한다. 재전송된 메세지이면 이전 처리 상태와 결과를 반환한다.
+func (t *ackTracker) receive(id uint64) (ackState, *deliveryAck) {
+
+	t.Lock()
+	defer t.Unlock()
+
+	if result, ok := t.history[id]; ok {
+		if result == nil {
+			return ackInProgress, nil
+		}
+		return ackDone, result
+	}
+
+	if len(t.order) >= defaultAckHistory {
+		delete(t.history, t.order[0])
+		t.order = t.order[1:]
+	}
+
+	t.history[id] = nil
+	t.order = append(t.order, id)
+
+	return ackNew, nil
+}
+
+// handled 는 받은 메세지의 처리 결과를 기억한다.
+func (t *ackTracker) handled(result *deliveryAck) {
+
+	t.Lock()
+	defer t.Unlock()
+
+	if _, ok := t.history[result.id]; ok {
+		t.history[result.id] = result
+	}
+}
+
+// acksEnabled 는 보내는 메세지에 ACK 를 요청할지 확인한다. 상대방도 handshake 에서 ACK 를 지원한다고 알려야 한다.
+func (conn *GrpcConnection) acksEnabled() bool {
+	return conn.ackOpts.Enabled && conn.GetFeatures().Has(FeatureAck)
+}
+
+// deliver 는 envelope 을 대기열에 넣는다. ACK 를 사용하면 일반 메세지의 callback 은 상대방의 ACK 를 받은 뒤에 호출된다.
+func (conn *GrpcConnection) deliver(ctx context.Context, envelope *pb.Envelope, successCallBack func(interface{}), errCallBack func(error)) error {
+
+	if !conn.acksEnabled() || envelope.Type != pb.Envelope_NORMAL || envelope.RequestId != 0 {
+		return conn.enqueueShared(ctx, envelope, successCallBack, errCallBack)
+	}
+
+	id, err := conn.acks.add(envelope.Protocol, envelope.Payload, successCallBack, errCallBack)
+	if err != nil {
+		return err
+	}
+
+	envelope.AckId = id
+
+	if err := conn.enqueueShared(ctx, envelope, conn.ackWritten(id), conn.ackFailed(id)); err != nil {
+		conn.acks.remove(id)
+		return err
+	}
+
+	return nil
+}
+
+func (conn *GrpcConnection) ackWritten(id uint64) func(interface{}) {
+	return func(interface{}) {
+		conn.acks.written(id, func() {
+			conn.retryAck(id)
+		})
+	}
+}
+
+func (conn *GrpcConnection) ackFailed(id uint64) func(error) {
+	return func(err error) {
+		conn.acks.resolve(id, err)
+	}
+}
+
+// retryAck 은 제한 시간 안에 ACK 를 받지 못한 메세지를 같은 ack ID 로 다시 보낸다.
+func (conn *GrpcConnection) retryAck(id uint64) {
+
+	p, ok := conn.acks.retry(id)
+	if !ok {
+		return
+	}
+
+	envelope := &pb.Envelope{Type: pb.Envelope_NORMAL, Protocol: p.protocol, Payload: p.payload, AckId: id}
+	if err := conn.enqueueShared(context.Background(), envelope, conn.ackWritten(id), conn.ackFailed(id)); err != nil {
+		conn.acks.resolve(id, err)
+	}
+}
+
+// receiveAck 은 상대방이 보낸 ACK 로 기다리는 메세지를 끝낸다.
+func (conn *GrpcConnection) receiveAck(envelope *pb.Envelope) {
+
+	ack := &pb.Ack{}
+	if err := proto.Unmarshal(envelope.Payload, ack); err != nil {
+		conn.serveError(err)
+		conn.report(EventMalformedMessage)
+		return
+	}
+
+	var err error
+	if AckCode(ack.Code) != AckDelivered {
+		err = &DeliveryError{Code: AckCode(ack.Code), Reason: ack.Reason}
+	}
+
+	// 재전송한 메세지의 ACK 가 여러 번 올 수 있으므로 처음 받은 ACK 만 사용한다.
+	if !conn.acks.resolve(ack.AckId, err) {
+		iLogger.Infof(nil, "[Bifrost] Drop ack for unknown message [%d]", ack.AckId)
+	}
+}
+
+// acceptAck 은 ACK 를 요청한 envelope 을 처리할지 정한다. 이미 처리한 envelope 이면 이전 결과를 다시 보내고 nil 을 반환한다.
+func (conn *GrpcConnection) acceptAck(envelope *pb.Envelope) (*deliveryAck, bool) {
+
+	state, result := conn.acks.receive(envelope.AckId)
+
+	switch state {
+	case ackInProgress:
+		return nil, false
+	case ackDone:
+		conn.sendAck(result)
+		return nil, false
+	}
+
+	return &deliveryAck{id: envelope.AckId}, true
+}
+
+// sendAck 은 처리 결과를 기억하고 ACK 를 보낸다.
+func (conn *GrpcConnection) sendAck(result *deliveryAck) {
+
+	conn.acks.handled(result)
+
+	payload, err := proto.Marshal(&pb.Ack{AckId: result.id, Code: uint32(result.code), Reason: result.reason})
+	if err != nil {
+		iLogger.Infof(nil, "[Bifrost] Fail to marshal ack [%s]", err.Error())
+		return
+	}
+
+	go conn.send(&pb.Envelope{Type: pb.Envelope_ACK, Payload: payload}, nil, nil)
+}
@@ -0,0 +1,83 @@
+package bifrost
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAckTracker_receive(t *testing.T) {
+	// given
+	tracker := newAckTracker(AckOpts{})
+
+	// when
+	first, _ := tracker.receive(1)
+	inProgress, _ := tracker.receive(1)
+	tracker.handled(&deliveryAck{id: 1, code: AckRejected, reason: "invalid"})
+	done, result := tracker.receive(1)
+
+	// then
+	assert.Equal(t, ackNew, first)
+	assert.Equal(t, ackInProgress, inProgress)
+	assert.Equal(t, ackDone, done)
+	assert.Equal(t, &deliveryAck{id: 1, code: AckRejected, reason: "invalid"}, result)
+}
+
+func TestAckTracker_receive_whenHistoryFull(t *testing.T) {
+	// given
+	tracker := newAckTracker(AckOpts{})
+
+	for id := uint64(1); id <= defaultAckHistory; id++ {
+		tracker.receive(id)
+	}
+
+	// when
+	tracker.receive(defaultAckHistory + 1)
+	state, _ := tracker.receive(1)
+
+	// then
+	// 가장 오래된 기록을 잊었으므로 다시 처리한다.
+	assert.Equal(t, ackNew, state)
+	assert.Len(t, tracker.history, defaultAckHistory)
+}
+
+func TestAckTracker_retry(t *testing.T) {
+	// given
+	tracker := newAckTracker(AckOpts{MaxRetries: 1})
+
+	errs := make(chan error, 1)
+	id, err := tracker.add("block", []byte("block"), nil, func(err error) {
+		errs <- err
+	})
+	assert.NoError(t, err)
+
+	// when
+	p, retried := tracker.retry(id)
+	_, retriedAgain := tracker.retry(id)
+
+	// then
+	assert.True(t, retried)
+	assert.Equal(t, []byte("block"), p.payload)
+	assert.False(t, retriedAgain)
+	assert.Equal(t, ErrAckTimeout, <-errs)
+}
+
+func TestAckTracker_closeAll(t *testing.T) {
+	// given
+	tracker := newAckTracker(AckOpts{})
+
+	errs := make(chan error, 1)
+	_, err := tracker.add("block", []byte("block"), nil, func(err error) {
+		errs <- err
+	})
+	assert.NoError(t, err)
+
+	// when
+	tracker.closeAll(ErrConnClosed)
+	_, addErr := tracker.add("block", []byte("block"), nil, nil)
+
+	// then
+	assert.Equal(t, ErrConnClosed, <-errs)
+	assert.True(t, errors.Is(addErr, ErrConnClosed))
+}
@@ -0,0 +1,139 @@
+package bifrost_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/DE-labtory/bifrost/mocks"
+	"github.com/DE-labtory/bifrost/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdvertisedFeatures_whenAckEnabled(t *testing.T) {
+	// when
+	features := bifrost.AdvertisedFeatures(bifrost.ConnOpts{Ack: bifrost.AckOpts{Enabled: true}})
+
+	// then
+	assert.Equal(t, bifrost.FeatureSet{bifrost.FeatureAck}, features)
+}
+
+func TestGrpcConnection_SendSync_whenAckEnabled(t *testing.T) {
+	// given
+	opts := bifrost.ConnOpts{Ack: bifrost.AckOpts{Enabled: true}}
+	localConn, remoteConn := newTestConnPairWithOpts(t, opts, opts, &bifrost.Session{Features: bifrost.FeatureSet{bifrost.FeatureAck}})
+
+	var handled int32
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		time.Sleep(100 * time.Millisecond)
+		atomic.StoreInt32(&handled, 1)
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// when
+	err := localConn.SendSync([]byte("block"), "block")
+
+	// then
+	// handler 가 처리를 마친 뒤에 반환한다.
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&handled))
+}
+
+func TestGrpcConnection_SendSync_whenRejected(t *testing.T) {
+	// given
+	opts := bifrost.ConnOpts{Ack: bifrost.AckOpts{Enabled: true}}
+	localConn, remoteConn := newTestConnPairWithOpts(t, opts, opts, &bifrost.Session{Features: bifrost.FeatureSet{bifrost.FeatureAck}})
+
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		message.Reject(bifrost.AckRejected, "invalid block")
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// when
+	err := localConn.SendSync([]byte("block"), "block")
+
+	// then
+	assert.Equal(t, &bifrost.DeliveryError{Code: bifrost.AckRejected, Reason: "invalid block"}, err)
+}
+
+func TestGrpcConnection_SendAsync_whenNoHandler(t *testing.T) {
+	// given
+	opts := bifrost.ConnOpts{Ack: bifrost.AckOpts{Enabled: true}}
+	localConn, remoteConn := newTestConnPairWithOpts(t, opts, opts, &bifrost.Session{Features: bifrost.FeatureSet{bifrost.FeatureAck}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// when
+	future := localConn.SendAsync([]byte("block"), "block")
+	<-future.Done()
+
+	// then
+	deliveryErr, ok := future.Err().(*bifrost.DeliveryError)
+	assert.True(t, ok)
+	assert.Equal(t, bifrost.AckUnhandled, deliveryErr.Code)
+}
+
+func TestGrpcConnection_Send_whenAckTimeout(t *testing.T) {
+	// given
+	localKeyOpts := mocks.NewMockKeyOpts()
+	remoteKeyOpts := mocks.NewMockKeyOpts()
+	local, remote := mocks.NewMockStreamPipe()
+
+	session := &bifrost.Session{Features: bifrost.FeatureSet{bifrost.FeatureAck}}
+	opts := bifrost.ConnOpts{Ack: bifrost.AckOpts{Enabled: true, Timeout: 50 * time.Millisecond, MaxRetries: 1}}
+
+	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, localKeyOpts.PubKey, remoteKeyOpts.PubKey, local, mocks.NewMockCryptoWithKey(localKeyOpts.PriKey), opts, session)
+	assert.NoError(t, err)
+
+	go conn.Start()
+	defer conn.Close()
+
+	// 상대방은 메세지를 받기만 하고 ACK 를 보내지 않는다.
+	received := make(chan *pb.Envelope, 3)
+	go func() {
+		for {
+			envelope, err := remote.Recv()
+			if err != nil {
+				return
+			}
+			received <- envelope
+		}
+	}()
+
+	// when
+	err = conn.SendSync([]byte("block"), "block")
+
+	// then
+	assert.Equal(t, bifrost.ErrAckTimeout, err)
+	assert.Len(t, received, 2)
+
+	first, retried := <-received, <-received
+	assert.NotZero(t, first.AckId)
+	assert.Equal(t, first.AckId, retried.AckId)
+	assert.NotEqual(t, first.Seq, retried.Seq)
+}
+
+func TestGrpcConnection_Send_whenAckNotNegotiated(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPair(t, bifrost.ConnOpts{Ack: bifrost.AckOpts{Enabled: true}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// when
+	err := localConn.SendSync([]byte("block"), "block")
+
+	// then
+	// 상대방이 ACK 를 지원하지 않으면 stream 에 쓴 뒤에 반환한다.
+	assert.NoError(t, err)
+}
@@ -4,11 +4,8 @@ import (
 	"time"
 
 	"context"
-	"encoding/json"
-	"errors"
 
 	"github.com/DE-labtory/bifrost"
-	"github.com/DE-labtory/bifrost/pb"
 
 	"github.com/DE-labtory/iLogger"
 	"google.golang.org/grpc"
@@ -16,7 +13,7 @@ import (
 )
 
 // handshake 과정에서 올바르지 않은 메세지 타입이 올 경우 발생하는 에러
-var ErrNotExpectedMessage = errors.New("wrong message type")
+var ErrNotExpectedMessage = bifrost.ErrUnexpectedMessage
 
 // defaultDialTimeout
 const (
@@ -25,18 +22,32 @@ const (
 
 // Server 와 연결시 사용되는 Client option
 type ClientOpts struct {
-	Ip     string
-	PubKey bifrost.Key
+	Ip       string
+	PubKey   bifrost.Key
+	ConnOpts bifrost.ConnOpts
+	// handshake 단계별 제한 시간
+	HandshakeOpts bifrost.HandshakeOpts
 }
 
 // Server 와 연결시 사용되는 grpc option.
 type GrpcOpts struct {
 	TlsEnabled bool
 	Creds      credentials.TransportCredentials
+	// server 의 TLS 인증서 public key 가 handshake 에서 받은 server 의 key 와 같은지 확인한다.
+	VerifyPeerCertKey bool
 }
 
 // 서버와 연결 요청. 실패시 err. handshake 과정을 거침.
 func Dial(serverIp string, metaData map[string]string, clientOpts ClientOpts, grpcOpts GrpcOpts, crypto bifrost.Crypto) (bifrost.Connection, error) {
+	return DialContext(context.Background(), serverIp, metaData, clientOpts, grpcOpts, crypto)
+}
+
+// DialContext 는 Dial 과 같지만, ctx 가 취소되면 handshake 를 중단한다.
+func DialContext(ctx context.Context, serverIp string, metaData map[string]string, clientOpts ClientOpts, grpcOpts GrpcOpts, crypto bifrost.Crypto) (bifrost.Connection, error) {
+
+	if err := clientOpts.ConnOpts.Reputation.CheckPeer("", serverIp); err != nil {
+		return nil, err
+	}
 
 	var opts []grpc.DialOption //required options
 
@@ -46,7 +57,7 @@ func Dial(serverIp string, metaData map[string]string, clientOpts ClientOpts, gr
 		opts = append(opts, grpc.WithInsecure())
 	}
 
-	dialContext, _ := context.WithTimeout(context.Background(), defaultDialTimeout)
+	dialContext, _ := context.WithTimeout(ctx, defaultDialTimeout)
 	gconn, err := grpc.DialContext(dialContext, serverIp, opts...)
 
 	if err != nil {
@@ -61,13 +72,13 @@ func Dial(serverIp string, metaData map[string]string, clientOpts ClientOpts, gr
 		return nil, err
 	}
 
-	serverPubKey, metaData, err := handShake(streamWrapper, metaData, clientOpts, crypto.KeyRecoverer)
+	result, err := handShake(ctx, streamWrapper, serverIp, metaData, clientOpts, grpcOpts, crypto)
 
 	if err != nil {
 		return nil, err
 	}
 
-	conn, err := bifrost.NewConnection(serverIp, metaData, serverPubKey, streamWrapper, crypto)
+	conn, err := bifrost.NewConnection(serverIp, result.PeerInfo.MetaData, clientOpts.PubKey, result.PeerKey, streamWrapper, crypto, clientOpts.ConnOpts, result.Session)
 
 	if err != nil {
 		return nil, err
@@ -76,86 +87,39 @@ func Dial(serverIp string, metaData map[string]string, clientOpts ClientOpts, gr
 	return conn, nil
 }
 
-// handshake 함수, return : serverPubKey, err
-func handShake(streamWrapper bifrost.StreamWrapper, metaData map[string]string, clientOpts ClientOpts, keyRecoverer bifrost.KeyRecoverer) (bifrost.Key, map[string]string, error) {
-
-	err := waitServer(streamWrapper)
-
-	if err != nil {
-		iLogger.Infof(nil, "[Bifrost] Waiting server failed [%s]", err.Error())
-		streamWrapper.Close()
-		return nil, nil, err
-	}
-
-	err = sendInfo(streamWrapper, clientOpts, metaData)
-	if err != nil {
-		iLogger.Infof(nil, "[Bifrost] Send info failed [%s]", err.Error())
-		streamWrapper.Close()
-		return nil, nil, err
-	}
+// handshake 함수. 실패하면 stream 은 닫힌다.
+func handShake(ctx context.Context, streamWrapper bifrost.StreamWrapper, serverIp string, metaData map[string]string, clientOpts ClientOpts, grpcOpts GrpcOpts, crypto bifrost.Crypto) (*bifrost.HandshakeResult, error) {
 
-	serverPubKey, metaD, err := getServerInfo(streamWrapper, keyRecoverer)
+	peerInfo, err := bifrost.NewPeerInfo(clientOpts.Ip, clientOpts.PubKey, metaData)
 
 	if err != nil {
-		iLogger.Infof(nil, "[Bifrost] Get server info failed [%s]", err.Error())
 		streamWrapper.Close()
-		return nil, nil, err
-	}
-
-	iLogger.Info(nil, "[Bifrost] Handshake success")
-
-	return serverPubKey, metaD, nil
-}
-
-// handshake 첫번째 과정 함수. server 의 request peer info 메세지를 기다린다.
-func waitServer(streamWrapper bifrost.StreamWrapper) error {
-	env, err := bifrost.RecvWithTimeout(10*time.Second, streamWrapper)
-	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if env.GetType() != pb.Envelope_REQUEST_PEERINFO {
-		return ErrNotExpectedMessage
-	}
-	return nil
-}
+	handshaker := bifrost.NewClientHandshaker(streamWrapper, crypto, peerInfo, clientOpts.ConnOpts, clientOpts.HandshakeOpts)
 
-// handShake 두번째 과정 함수. client 의 peer info 메세지를 server 에게 전달한다.
-func sendInfo(streamWrapper bifrost.StreamWrapper, clientOpts ClientOpts, metaData map[string]string) error {
-	env, err := bifrost.BuildResponsePeerInfo(clientOpts.Ip, clientOpts.PubKey, metaData)
+	// 차단된 server 이거나 TLS 인증서의 key 가 server 의 key 와 다르면 연결하지 않는다.
+	handshaker.OnPeerInfo(func(peerKey bifrost.Key, peerInfo *bifrost.PeerInfo) error {
+		if err := clientOpts.ConnOpts.Reputation.CheckPeer(peerKey.ID(), serverIp); err != nil {
+			return err
+		}
 
-	if err != nil {
-		return err
-	}
-	err = streamWrapper.Send(env)
+		if grpcOpts.TlsEnabled && grpcOpts.VerifyPeerCertKey {
+			return bifrost.VerifyPeerCertificateKey(streamWrapper.Context(), peerKey)
+		}
 
-	if err != nil {
-		return err
-	}
-	return nil
-}
+		return nil
+	})
 
-// handShake 세번째 과정 함수. server 의 peer info 메세지를 기다린다(Get 한다).
-func getServerInfo(streamWrapper bifrost.StreamWrapper, keyRecoverer bifrost.KeyRecoverer) (bifrost.Key, map[string]string, error) {
-	env, err := bifrost.RecvWithTimeout(3*time.Second, streamWrapper)
+	result, err := handshaker.Run(ctx)
 
 	if err != nil {
-		return nil, nil, err
-	}
-
-	peerInfo := &bifrost.PeerInfo{}
-
-	err = json.Unmarshal(env.Payload, peerInfo)
-
-	if err != nil {
-		return nil, nil, err
+		iLogger.Infof(nil, "[Bifrost] Handshake failed [%s]", err.Error())
+		return nil, err
 	}
 
-	serverPubKey, err := keyRecoverer.RecoverKeyFromByte(peerInfo.PubKeyBytes, peerInfo.IsPrivate)
-
-	if err != nil {
-		return nil, nil, err
-	}
+	iLogger.Info(nil, "[Bifrost] Handshake success")
 
-	return serverPubKey, peerInfo.MetaData, nil
+	return result, nil
 }
@@ -1,6 +1,7 @@
 package client_test
 
 import (
+	"errors"
 	"testing"
 
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/DE-labtory/bifrost"
 	"github.com/DE-labtory/bifrost/client"
 	"github.com/DE-labtory/bifrost/mocks"
+	"github.com/DE-labtory/bifrost/server"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -39,7 +41,7 @@ func TestDial(t *testing.T) {
 	time.Sleep(3 * time.Second)
 
 	// when
-	testConn, err := client.Dial(serverIP, nil, clientOpt, grpcOpt, mocks.NewMockCrypto())
+	testConn, err := client.Dial(serverIP, nil, clientOpt, grpcOpt, mocks.NewMockCryptoWithKey(keyPair.PriKey))
 	go func() {
 		defer testConn.Close()
 		if err := testConn.Start(); err != nil {
@@ -51,3 +53,169 @@ func TestDial(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, testConn.GetIP(), bifrost.Address{serverIP})
 }
+
+func TestDial_whenPeerRejected(t *testing.T) {
+	// given
+	keyPair := mocks.NewMockKeyOpts()
+
+	clientOpt := client.ClientOpts{
+		Ip:     "127.0.0.1:12346",
+		PubKey: keyPair.PubKey,
+	}
+
+	serverIP := "127.0.0.1:43214"
+	s := mocks.NewMockServer()
+	s.SetAuthorizer(server.AuthorizerFunc(func(peerKey bifrost.Key, peerInfo bifrost.PeerInfo, remoteAddress string) error {
+		return errors.New("not in allowlist")
+	}))
+	go s.Listen(serverIP)
+	defer s.Stop()
+	time.Sleep(3 * time.Second)
+
+	// when
+	_, err := client.Dial(serverIP, nil, clientOpt, client.GrpcOpts{}, mocks.NewMockCryptoWithKey(keyPair.PriKey))
+
+	// then
+	assert.Equal(t, &bifrost.PeerRejectedError{Reason: "not in allowlist"}, err)
+}
+
+func TestDial_whenEncryptionEnabled(t *testing.T) {
+	// given
+	keyPair := mocks.NewMockKeyOpts()
+
+	clientOpt := client.ClientOpts{
+		Ip:       "127.0.0.1:12347",
+		PubKey:   keyPair.PubKey,
+		ConnOpts: bifrost.ConnOpts{EncryptionEnabled: true},
+	}
+
+	received := make(chan []byte, 1)
+	serverIP := "127.0.0.1:43215"
+	s := mocks.NewMockServer()
+	s.SetConnOpts(bifrost.ConnOpts{EncryptionEnabled: true, Protocols: []string{"test"}})
+	s.OnConnection(func(connection bifrost.Connection) {
+		defer connection.Close()
+
+		connection.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+			received <- message.Data
+		}})
+		connection.Start()
+	})
+	go s.Listen(serverIP)
+	defer s.Stop()
+	time.Sleep(3 * time.Second)
+
+	// when
+	testConn, err := client.Dial(serverIP, nil, clientOpt, client.GrpcOpts{}, mocks.NewMockCryptoWithKey(keyPair.PriKey))
+	assert.NoError(t, err)
+	defer testConn.Close()
+	go testConn.Start()
+
+	testConn.Send([]byte("secret"), "test", nil, nil)
+
+	// then
+	assert.Equal(t, bifrost.FeatureSet{bifrost.FeatureEncryption}, testConn.GetFeatures())
+	assert.Equal(t, []string{"test"}, testConn.GetPeerProtocols())
+	assert.Equal(t, []byte("secret"), <-received)
+}
+
+func TestDial_whenServerEncryptionDisabled(t *testing.T) {
+	// given
+	keyPair := mocks.NewMockKeyOpts()
+
+	clientOpt := client.ClientOpts{
+		Ip:       "127.0.0.1:12348",
+		PubKey:   keyPair.PubKey,
+		ConnOpts: bifrost.ConnOpts{EncryptionEnabled: true},
+	}
+
+	serverIP := "127.0.0.1:43216"
+	s := mocks.NewMockServer()
+	go s.Listen(serverIP)
+	defer s.Stop()
+	time.Sleep(3 * time.Second)
+
+	// when
+	_, err := client.Dial(serverIP, nil, clientOpt, client.GrpcOpts{}, mocks.NewMockCryptoWithKey(keyPair.PriKey))
+
+	// then
+	assert.Equal(t, bifrost.ErrEncryptionNotSupported, err)
+}
+
+func TestDial_whenMutualTLS(t *testing.T) {
+	// given
+	ca, err := mocks.NewMockCA()
+	assert.NoError(t, err)
+
+	serverKeyPair := mocks.NewMockKeyOpts()
+	serverCert, err := ca.Issue(serverKeyPair.PriKey)
+	assert.NoError(t, err)
+
+	clientKeyPair := mocks.NewMockKeyOpts()
+	clientCert, err := ca.Issue(clientKeyPair.PriKey)
+	assert.NoError(t, err)
+
+	serverIP := "127.0.0.1:43217"
+	s := server.New(serverKeyPair, mocks.NewMockCryptoWithKey(serverKeyPair.PriKey), nil)
+	s.SetGrpcOpts(server.GrpcOpts{
+		TlsEnabled:        true,
+		Creds:             server.NewMutualTLSCreds(serverCert, ca.Pool),
+		VerifyPeerCertKey: true,
+	})
+	go s.Listen(serverIP)
+	defer s.Stop()
+	time.Sleep(3 * time.Second)
+
+	clientOpt := client.ClientOpts{Ip: "127.0.0.1:12349", PubKey: clientKeyPair.PubKey}
+	grpcOpt := client.GrpcOpts{
+		TlsEnabled:        true,
+		Creds:             client.NewMutualTLSCreds(clientCert, ca.Pool, "127.0.0.1"),
+		VerifyPeerCertKey: true,
+	}
+
+	// when
+	testConn, err := client.Dial(serverIP, nil, clientOpt, grpcOpt, mocks.NewMockCryptoWithKey(clientKeyPair.PriKey))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, serverKeyPair.PubKey.ID(), testConn.GetPeerKey().ID())
+	testConn.Close()
+}
+
+func TestDial_whenCertKeyMismatch(t *testing.T) {
+	// given
+	ca, err := mocks.NewMockCA()
+	assert.NoError(t, err)
+
+	serverKeyPair := mocks.NewMockKeyOpts()
+	serverCert, err := ca.Issue(serverKeyPair.PriKey)
+	assert.NoError(t, err)
+
+	// client 인증서와 다른 key 로 handshake
+	clientKeyPair := mocks.NewMockKeyOpts()
+	clientCert, err := ca.Issue(mocks.NewMockKeyOpts().PriKey)
+	assert.NoError(t, err)
+
+	serverIP := "127.0.0.1:43218"
+	s := server.New(serverKeyPair, mocks.NewMockCryptoWithKey(serverKeyPair.PriKey), nil)
+	s.SetGrpcOpts(server.GrpcOpts{
+		TlsEnabled:        true,
+		Creds:             server.NewMutualTLSCreds(serverCert, ca.Pool),
+		VerifyPeerCertKey: true,
+	})
+	go s.Listen(serverIP)
+	defer s.Stop()
+	time.Sleep(3 * time.Second)
+
+	clientOpt := client.ClientOpts{Ip: "127.0.0.1:12350", PubKey: clientKeyPair.PubKey}
+	grpcOpt := client.GrpcOpts{
+		TlsEnabled: true,
+		Creds:      client.NewMutualTLSCreds(clientCert, ca.Pool, "127.0.0.1"),
+	}
+
+	// when
+	_, err = client.Dial(serverIP, nil, clientOpt, grpcOpt, mocks.NewMockCryptoWithKey(clientKeyPair.PriKey))
+
+	// then
+	assert.Equal(t, &bifrost.PeerRejectedError{Reason: bifrost.ErrCertKeyMismatch.Error()}, err)
+}
@@ -0,0 +1,22 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// NewTLSCreds 는 rootCAs 로 server 인증서를 검증하는 TLS credentials 를 만든다.
+func NewTLSCreds(rootCAs *x509.CertPool, serverName string) credentials.TransportCredentials {
+	return credentials.NewClientTLSFromCert(rootCAs, serverName)
+}
+
+// NewMutualTLSCreds 는 server 에게 client 인증서를 제시하는 mutual TLS credentials 를 만든다.
+func NewMutualTLSCreds(cert tls.Certificate, rootCAs *x509.CertPool, serverName string) credentials.TransportCredentials {
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      rootCAs,
+		ServerName:   serverName,
+	})
+}
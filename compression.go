@@ -0,0 +1,148 @@
+package bifrost
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/DE-labtory/bifrost/pb"
+)
+
+// 압축을 푼 payload 가 제한보다 큰 경우 발생하는 에러
+var ErrDecompressedTooLarge = errors.New("decompressed payload too large")
+
+// handshake 에서 합의하지 않은 방식으로 압축된 envelope 을 받은 경우 발생하는 에러
+var ErrCompressionNotNegotiated = errors.New("compression not negotiated")
+
+const (
+	defaultCompressionThreshold = 1024
+	defaultMaxDecompressedSize  = 16 * 1024 * 1024
+)
+
+var compressionFeatures = map[pb.Compression]Feature{
+	pb.Compression_DEFLATE: FeatureCompressionDeflate,
+	pb.Compression_GZIP:    FeatureCompressionGzip,
+}
+
+// CompressionOpts 는 payload 압축 설정. 값을 지정하지 않은(zero value) field 는 기본값을 사용한다.
+type CompressionOpts struct {
+	// handshake 에서 압축을 지원한다고 알리고, 합의하면 payload 를 압축해서 보낸다.
+	Enabled bool
+	// 선호하는 순서의 압축 방식. 기본값은 deflate, gzip.
+	Algorithms []pb.Compression
+	// 이 크기(byte) 이상인 payload 만 압축한다. 기본값은 1KB.
+	Threshold int
+	// 받은 payload 의 압축을 풀었을 때 허용하는 최대 크기(byte). 기본값은 16MB.
+	MaxDecompressedSize int
+}
+
+// algorithms 는 handshake 에서 알릴 압축 기능 목록을 선호하는 순서로 만든다.
+func (opts CompressionOpts) algorithms() FeatureSet {
+
+	algorithms := opts.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = []pb.Compression{pb.Compression_DEFLATE, pb.Compression_GZIP}
+	}
+
+	features := FeatureSet{}
+	for _, algorithm := range algorithms {
+		if feature, ok := compressionFeatures[algorithm]; ok {
+			features = append(features, feature)
+		}
+	}
+
+	return features
+}
+
+// compression 은 보내는 envelope 에 사용할 압축 방식을 반환한다.
+// 합의한 기능은 자신이 알린 순서를 따르므로 가장 선호하는 방식을 사용한다. s 가 nil 이면 압축하지 않는다.
+func (s *Session) compression() pb.Compression {
+
+	if s == nil {
+		return pb.Compression_NO_COMPRESSION
+	}
+
+	for _, feature := range s.Features {
+		for algorithm, f := range compressionFeatures {
+			if f == feature {
+				return algorithm
+			}
+		}
+	}
+
+	return pb.Compression_NO_COMPRESSION
+}
+
+// supportsCompression 은 handshake 에서 algorithm 을 합의했는지 확인한다.
+func (s *Session) supportsCompression(algorithm pb.Compression) bool {
+
+	feature, ok := compressionFeatures[algorithm]
+
+	return ok && s != nil && s.Features.Has(feature)
+}
+
+func compress(algorithm pb.Compression, data []byte) ([]byte, error) {
+
+	buf := &bytes.Buffer{}
+
+	var w io.WriteCloser
+	var err error
+
+	switch algorithm {
+	case pb.Compression_DEFLATE:
+		w, err = flate.NewWriter(buf, flate.DefaultCompression)
+	case pb.Compression_GZIP:
+		w = gzip.NewWriter(buf)
+	default:
+		return nil, ErrCompressionNotNegotiated
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decompress 는 압축을 푼다. 압축 폭탄으로 메모리를 소진하지 않도록 maxSize 까지만 읽는다.
+func decompress(algorithm pb.Compression, data []byte, maxSize int) ([]byte, error) {
+
+	var r io.ReadCloser
+	var err error
+
+	switch algorithm {
+	case pb.Compression_DEFLATE:
+		r = flate.NewReader(bytes.NewReader(data))
+	case pb.Compression_GZIP:
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	default:
+		return nil, ErrCompressionNotNegotiated
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	decompressed, err := ioutil.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(decompressed) > maxSize {
+		return nil, ErrDecompressedTooLarge
+	}
+
+	return decompressed, nil
+}
@@ -0,0 +1,52 @@
+package bifrost
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/DE-labtory/bifrost/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecompress(t *testing.T) {
+	for _, algorithm := range []pb.Compression{pb.Compression_DEFLATE, pb.Compression_GZIP} {
+		// given
+		data := bytes.Repeat([]byte("transaction"), 100)
+
+		compressed, err := compress(algorithm, data)
+		assert.NoError(t, err)
+
+		// when
+		decompressed, err := decompress(algorithm, compressed, len(data))
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, data, decompressed)
+	}
+}
+
+func TestDecompress_whenTooLarge(t *testing.T) {
+	// given
+	bomb, err := compress(pb.Compression_GZIP, make([]byte, 10*1024*1024))
+	assert.NoError(t, err)
+
+	// when
+	_, err = decompress(pb.Compression_GZIP, bomb, 1024*1024)
+
+	// then
+	assert.Equal(t, ErrDecompressedTooLarge, err)
+}
+
+func TestGrpcConnection_decompress_whenNotNegotiated(t *testing.T) {
+	// given
+	conn := &GrpcConnection{session: &Session{Features: FeatureSet{FeatureCompressionDeflate}}}
+
+	compressed, err := compress(pb.Compression_GZIP, []byte("hello"))
+	assert.NoError(t, err)
+
+	// when
+	ok := conn.decompress(&pb.Envelope{Payload: compressed, Compression: pb.Compression_GZIP})
+
+	// then
+	assert.False(t, ok)
+}
@@ -0,0 +1,73 @@
+package bifrost_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/DE-labtory/bifrost/mocks"
+	"github.com/DE-labtory/bifrost/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdvertisedFeatures_whenCompressionEnabled(t *testing.T) {
+	// given
+	opts := bifrost.ConnOpts{Compression: bifrost.CompressionOpts{Enabled: true, Algorithms: []pb.Compression{pb.Compression_GZIP}}}
+
+	// when
+	features := bifrost.AdvertisedFeatures(opts)
+
+	// then
+	assert.Equal(t, bifrost.FeatureSet{bifrost.FeatureCompressionGzip}, features)
+}
+
+func TestGrpcConnection_Send_whenCompressionNegotiated(t *testing.T) {
+	// given
+	session := &bifrost.Session{Features: bifrost.FeatureSet{bifrost.FeatureCompressionGzip}}
+	localConn, remoteConn := newTestConnPairWithOpts(t, bifrost.ConnOpts{}, bifrost.ConnOpts{}, session)
+
+	received := make(chan []byte, 1)
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		received <- message.Data
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	data := bytes.Repeat([]byte("block"), 1000)
+
+	// when
+	err := localConn.SendSync(data, "block")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, data, <-received)
+}
+
+func TestGrpcConnection_Send_whenPayloadCompressed(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+
+	session := &bifrost.Session{Features: bifrost.FeatureSet{bifrost.FeatureCompressionDeflate}}
+	opts := bifrost.ConnOpts{Compression: bifrost.CompressionOpts{Threshold: 100}}
+
+	conn, remote := newTestConn(t, keyOpts, opts, session)
+
+	go conn.Start()
+	defer conn.Close()
+
+	// when
+	conn.Send(bytes.Repeat([]byte("a"), 99), "small", nil, nil)
+	conn.Send(bytes.Repeat([]byte("a"), 1000), "large", nil, nil)
+
+	// then
+	small, err := remote.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, pb.Compression_NO_COMPRESSION, small.Compression)
+
+	large, err := remote.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, pb.Compression_DEFLATE, large.Compression)
+	assert.True(t, len(large.Payload) < 1000)
+}
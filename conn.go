@@ -1,15 +1,22 @@
 package bifrost
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/DE-labtory/bifrost/pb"
 	"github.com/DE-labtory/iLogger"
+	"github.com/golang/protobuf/proto"
 )
 
+var ErrInvalidRelayMessage = errors.New("invalid relay message")
+
 type ConnID = string
 
 type PeerInfo struct {
@@ -17,6 +24,16 @@ type PeerInfo struct {
 	PubKeyBytes []byte
 	IsPrivate   bool
 	MetaData    map[string]string
+	// 상대방이 서명해야 할 nonce
+	Nonce []byte
+	// 암호화를 사용할 경우 session key 합의에 사용할 ephemeral public key
+	EphemeralKey []byte
+	// wire protocol version
+	Version uint32
+	// 지원하는 선택 기능
+	Features FeatureSet
+	// 처리하는 mux protocol 목록
+	Protocols []string
 }
 
 type innerMessage struct {
@@ -25,52 +42,188 @@ type innerMessage struct {
 	OnSuccess func(interface{})
 }
 
+// 받기 제한을 기다리는 메세지 대기열의 크기
+const inboundQueueSize = 256
+
+// inboundEnvelope 은 받기 제한을 기다리는 메세지이다. size 는 복호화하거나 압축을 풀기 전의 크기이다.
+type inboundEnvelope struct {
+	envelope *pb.Envelope
+	size     int
+}
+
 type Message struct {
 	Envelope *pb.Envelope
 	Data     []byte
 	Conn     Connection
+	// 메세지를 작성하고 서명한 peer 의 key. 전달(relay)된 메세지는 Conn 의 peer 와 다르다.
+	Origin Key
+	// 상대방이 SendStream 으로 보낸 메세지이면 payload 를 읽을 수 있는 reader 이며, Data 는 nil 이다.
+	// handler 는 별도의 goroutine 에서 호출되며, 끝까지 읽지 않을 경우 Close 해야 한다.
+	Stream *StreamReader
+	// 상대방이 Request 로 보낸 메세지의 request ID. 응답을 기다리지 않는 메세지는 0 이다.
+	requestID uint64
+	// 상대방이 ACK 를 요청한 메세지의 처리 결과. ACK 를 요청하지 않은 메세지는 nil 이다.
+	ack *deliveryAck
 }
 
 // Respond sends a msg to the source that sent the ReceivedMessageImpl
+// 상대방이 Request 로 보낸 메세지이면 응답을 기다리는 Request 에게 전달된다.
 func (m *Message) Respond(data []byte, protocol string, successCallBack func(interface{}), errCallBack func(error)) {
 
+	if m.requestID != 0 {
+		m.Conn.Reply(m.requestID, data, protocol, successCallBack, errCallBack)
+		return
+	}
+
 	m.Conn.Send(data, protocol, successCallBack, errCallBack)
 }
 
 type Handler interface {
 	ServeRequest(msg Message)
+	ServeError(conn Connection, err error)
+}
+
+// Connection 의 동작을 설정하는 option. 값을 지정하지 않은(zero value) field 는 기본값을 사용한다.
+type ConnOpts struct {
+	// 중복 검사를 위해 기억하는 sequence number 의 범위. 기본값은 1024.
+	ReplayWindow uint64
+	// 수신한 envelope 의 timestamp 와 현재 시간의 허용 차이. 기본값은 1분.
+	MaxMessageAge time.Duration
+	// 서명 검증에 실패한 envelope 의 처리 정책. 기본값은 InvalidMessageDropAndReport.
+	InvalidMessagePolicy InvalidMessagePolicy
+	// handshake 에서 session key 를 합의해 payload 를 암호화한다. 상대방도 사용하도록 설정해야 연결할 수 있다.
+	EncryptionEnabled bool
+	// handshake 에서 상대방에게 알릴 mux protocol 목록
+	Protocols []string
+	// 잘못된 메세지를 보낸 peer 를 기록하고 차단한다. nil 이면 사용하지 않는다.
+	Reputation *Reputation
+	// protocol 별 전송 우선순위. 지정하지 않은 protocol 은 PriorityNormal 을 사용한다.
+	Priorities map[string]Priority
+	// 우선순위별 lane 이 한 번에 보내는 메세지 수의 비율. 기본값은 High 8, Normal 4, Low 1.
+	PriorityWeights map[Priority]int
+	// SendStream 이 한 envelope 에 담는 최대 byte 수. 기본값은 1MB.
+	ChunkSize int
+	// SendStream 에서 상대방이 읽기 전에 보낼 수 있는 chunk 수. 첫 chunk 로 상대방에게 알린다. 기본값은 8, 최대 64.
+	StreamWindow int
+	// 상대방이 동시에 보낼 수 있는 stream 수. 넘는 stream 은 중단한다. 기본값은 16.
+	MaxIncomingStreams int
+	// payload 압축 설정. 상대방도 사용하도록 설정해야 압축해서 보낸다.
+	Compression CompressionOpts
+	// PING 을 보내는 주기. 0 이면 보내지 않는다. 상대방의 PING 에는 항상 응답한다.
+	HeartbeatInterval time.Duration
+	// 연속으로 응답하지 않은 PING 이 이 수가 되면 연결을 끊는다. 기본값은 3.
+	MaxMissedHeartbeats int
+	// 받은 메세지의 handler 호출을 실행할 Dispatcher. 여러 connection 이 공유할 수 있다.
+	// nil 이고 DispatchWorkers 가 0 이면 read loop 에서 바로 handler 를 호출한다.
+	Dispatcher Dispatcher
+	// Dispatcher 가 nil 일 때 connection 마다 만들 WorkerPool 의 worker 수
+	DispatchWorkers int
+	// 순서를 유지할 단위를 정한다. 같은 key 의 메세지는 순서대로 처리한다. 기본값은 메세지의 protocol.
+	DispatchKey func(msg Message) string
+	// connection 의 보내기, 받기 제한. PING, ACK 같은 제어 메세지는 제한하지 않는다.
+	SendLimit    RateLimit
+	ReceiveLimit RateLimit
+	// protocol 별 보내기, 받기 제한
+	ProtocolSendLimits    map[string]RateLimit
+	ProtocolReceiveLimits map[string]RateLimit
+	// 상대방이 handler 로 처리했는지 확인하는 ACK 설정. 상대방도 사용하도록 설정해야 ACK 를 요청한다.
+	Ack AckOpts
 }
 
 type Connection interface {
 	Send(data []byte, protocol string, successCallBack func(interface{}), errCallBack func(error))
+	SendContext(ctx context.Context, data []byte, protocol string) error
+	SendSync(data []byte, protocol string) error
+	SendAsync(data []byte, protocol string) *SendFuture
+	SendStream(ctx context.Context, r io.Reader, protocol string) error
+	Forward(envelope *pb.Envelope, successCallBack func(interface{}), errCallBack func(error))
+	Request(ctx context.Context, payload []byte, protocol string) ([]byte, error)
+	Reply(requestID uint64, payload []byte, protocol string, successCallBack func(interface{}), errCallBack func(error))
 	Close()
 	GetIP() Address
 	GetPeerKey() Key
 	GetID() ConnID
 	GetMetaData() map[string]string
+	GetFeatures() FeatureSet
+	GetPeerProtocols() []string
+	RTT() time.Duration
+	CloseGracefully(ctx context.Context, reason CloseReason) error
+	State() ConnState
+	Done() <-chan struct{}
+	Err() error
+	OnStateChange(listener StateChangeListener)
+	Stats() ConnStats
+	SetGlobalRateLimiters(send *RateLimiter, receive *RateLimiter)
 	Start() error
 	Handle(handler Handler)
+	RotateKey(newKey Key, newSigner Signer, successCallBack func(interface{}), errCallBack func(error))
+	OnKeyRotation(listener KeyRotationListener)
 }
 
 type GrpcConnection struct {
-	ID            ConnID
-	peerKey       Key
+	// key rotation 으로 바뀔 수 있으므로 *peerIdentity 를 atomic 하게 바꾼다.
+	peer          atomic.Value
 	ip            Address
 	streamWrapper StreamWrapper
 	stopFlag      int32
-	handler       Handler
-	outChannl     chan *innerMessage
-	readChannel   chan *pb.Envelope
-	stopChannel   chan struct{}
+	// CloseGracefully 가 호출되면 1 이 되며, 이후에는 메세지를 보낼 수 없다.
+	draining    int32
+	handler     Handler
+	outbound    *outboundQueue
+	priorities  map[string]Priority
+	readChannel chan *pb.Envelope
+	// 받기 제한을 기다리는 제어 메세지가 아닌 메세지
+	inbound     chan inboundEnvelope
+	stopChannel chan struct{}
+	// Close 가 호출되면 닫힌다. 전송 대기 중인 sender 를 깨우는 데 사용한다.
+	closed chan struct{}
+	// 연결이 끊긴 원인. closed 가 닫히기 전에 기록한다.
+	err            error
+	state          int32
+	stateLock      sync.Mutex
+	stateListeners []StateChangeListener
+	// readStream 이 끝나면 닫힌다. 상대방이 연결을 끊었는지 확인하는 데 사용한다.
+	readDone chan struct{}
+	// 보내는 쪽은 read lock 을 잡고 동시에 서명한다. key rotation 과 Close 는 write lock 을 잡는다.
 	sync.RWMutex
 	metaData map[string]string
+	// lane 별 sequence number. lane 을 weighted round robin 으로 꺼내므로 lane 사이에서는 순서가 바뀐다.
+	seqs [priorityCount]uint64
+	// lane 별 replay 검사
+	replayGuards         [priorityCount]*replayGuard
+	relayGuard           *relayGuard
+	invalidMessagePolicy InvalidMessagePolicy
+	invalidMessages      *failureCounter
+	session              *Session
+	localKeyBytes        []byte
+	rotationLock         sync.Mutex
+	rotationListeners    []KeyRotationListener
+	reputation           *Reputation
+	requestSeq           uint64
+	pending              *pendingRequests
+	transferSeq          uint64
+	transfers            *transfers
+	chunkSize            int
+	streamWindow         int
+	compression          CompressionOpts
+	heartbeat            *heartbeat
+	dispatcher           Dispatcher
+	dispatchKey          func(msg Message) string
+	stats                *connStats
+	limiters             *rateLimiters
+	ackOpts              AckOpts
+	acks                 *ackTracker
+	// connection 이 만든 WorkerPool 이면 연결이 끊길 때 멈춘다.
+	ownedPool *WorkerPool
 	Crypto
 }
 
-func NewConnection(ip string, metaData map[string]string, peerKey Key, streamWrapper StreamWrapper, crypto Crypto) (Connection, error) {
+// session 은 handshake 에서 합의한 session 이며, key 를 합의하지 않았으면 payload 를 암호화하지 않는다.
+// localKey 는 자신의 public key 이며, 보내는 envelope 마다 작성자 key 로 담긴다.
+func NewConnection(ip string, metaData map[string]string, localKey Key, peerKey Key, streamWrapper StreamWrapper, crypto Crypto, opts ConnOpts, session *Session) (Connection, error) {
 
-	if streamWrapper == nil || peerKey == nil {
-		return nil, errors.New("fail to create connection streamWrapper or peerKey is nil")
+	if streamWrapper == nil || localKey == nil || peerKey == nil {
+		return nil, errors.New("fail to create connection streamWrapper, localKey or peerKey is nil")
 	}
 
 	ipAddr, err := ToAddress(ip)
@@ -78,33 +231,131 @@ func NewConnection(ip string, metaData map[string]string, peerKey Key, streamWra
 		return nil, err
 	}
 
-	return &GrpcConnection{
-		ID:            peerKey.ID(),
-		peerKey:       peerKey,
-		ip:            ipAddr,
-		streamWrapper: streamWrapper,
-		outChannl:     make(chan *innerMessage, 200),
-		readChannel:   make(chan *pb.Envelope, 200),
-		stopChannel:   make(chan struct{}, 1),
-		Crypto:        crypto,
-		metaData:      metaData,
-	}, nil
+	localKeyBytes, err := localKey.ToByte()
+	if err != nil {
+		return nil, err
+	}
+
+	peerKeyBytes, err := peerKey.ToByte()
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &GrpcConnection{
+		ip:                   ipAddr,
+		streamWrapper:        streamWrapper,
+		outbound:             newOutboundQueue(defaultLaneSize, opts.PriorityWeights),
+		priorities:           opts.Priorities,
+		readChannel:          make(chan *pb.Envelope, 200),
+		inbound:              make(chan inboundEnvelope, inboundQueueSize),
+		stopChannel:          make(chan struct{}, 1),
+		closed:               make(chan struct{}),
+		readDone:             make(chan struct{}),
+		Crypto:               crypto,
+		metaData:             metaData,
+		relayGuard:           newRelayGuard(opts.MaxMessageAge),
+		invalidMessagePolicy: opts.InvalidMessagePolicy,
+		invalidMessages:      newFailureCounter(opts.InvalidMessagePolicy.Window),
+		session:              session,
+		localKeyBytes:        localKeyBytes,
+		reputation:           opts.Reputation,
+		pending:              newPendingRequests(),
+		transfers:            newTransfers(opts.MaxIncomingStreams),
+		chunkSize:            opts.ChunkSize,
+		streamWindow:         opts.StreamWindow,
+		compression:          opts.Compression,
+		heartbeat:            newHeartbeat(opts.HeartbeatInterval, opts.MaxMissedHeartbeats),
+		dispatcher:           opts.Dispatcher,
+		dispatchKey:          opts.DispatchKey,
+		stats:                newConnStats(),
+		limiters:             newRateLimiters(opts),
+		ackOpts:              opts.Ack,
+		acks:                 newAckTracker(opts.Ack),
+	}
+
+	for i := range conn.replayGuards {
+		conn.replayGuards[i] = newReplayGuard(opts.ReplayWindow, opts.MaxMessageAge)
+	}
+
+	if conn.dispatcher == nil && opts.DispatchWorkers > 0 {
+		conn.ownedPool = NewWorkerPool(opts.DispatchWorkers, 0)
+		conn.dispatcher = conn.ownedPool
+	}
+
+	if conn.dispatcher == nil {
+		conn.dispatcher = inlineDispatcher{}
+	}
+
+	if conn.dispatchKey == nil {
+		conn.dispatchKey = protocolKey
+	}
+
+	if conn.chunkSize <= 0 {
+		conn.chunkSize = defaultChunkSize
+	}
+
+	if conn.streamWindow <= 0 {
+		conn.streamWindow = defaultStreamWindow
+	}
+
+	if conn.streamWindow > maxStreamWindow {
+		conn.streamWindow = maxStreamWindow
+	}
+
+	if conn.compression.Threshold <= 0 {
+		conn.compression.Threshold = defaultCompressionThreshold
+	}
+
+	if conn.compression.MaxDecompressedSize <= 0 {
+		conn.compression.MaxDecompressedSize = defaultMaxDecompressedSize
+	}
+
+	conn.peer.Store(&peerIdentity{key: peerKey, keyBytes: peerKeyBytes})
+
+	return conn, nil
 }
 
 func (conn *GrpcConnection) GetMetaData() map[string]string {
 	return conn.metaData
 }
 
+// GetFeatures 는 handshake 에서 상대방과 합의한 기능 목록을 반환한다.
+func (conn *GrpcConnection) GetFeatures() FeatureSet {
+	if conn.session == nil {
+		return FeatureSet{}
+	}
+
+	return conn.session.Features
+}
+
+// GetPeerProtocols 는 상대방이 handshake 에서 알려준 mux protocol 목록을 반환한다.
+func (conn *GrpcConnection) GetPeerProtocols() []string {
+	if conn.session == nil {
+		return nil
+	}
+
+	return conn.session.PeerProtocols
+}
+
+// RTT 는 heartbeat 로 측정한 평활화된 왕복 시간이다. 아직 측정하지 않았으면 0 이다.
+func (conn *GrpcConnection) RTT() time.Duration {
+	return conn.heartbeat.rtt()
+}
+
 func (conn *GrpcConnection) GetIP() Address {
 	return conn.ip
 }
 
 func (conn *GrpcConnection) GetPeerKey() Key {
-	return conn.peerKey
+	return conn.identity().key
 }
 
 func (conn *GrpcConnection) GetID() ConnID {
-	return conn.ID
+	return conn.identity().key.ID()
+}
+
+func (conn *GrpcConnection) identity() *peerIdentity {
+	return conn.peer.Load().(*peerIdentity)
 }
 
 func (conn *GrpcConnection) toDie() bool {
@@ -116,45 +367,346 @@ func (conn *GrpcConnection) Handle(handler Handler) {
 }
 
 func (conn *GrpcConnection) Send(payload []byte, protocol string, successCallBack func(interface{}), errCallBack func(error)) {
+	conn.send(&pb.Envelope{Type: pb.Envelope_NORMAL, Protocol: protocol, Payload: payload}, successCallBack, errCallBack)
+}
+
+// SendContext 는 payload 를 보내고 stream 에 쓸 때까지 기다린다. ACK 를 사용하면 상대방의 ACK 를 받을 때까지 기다린다.
+// 전송 전에 connection 이 닫히면 ErrConnClosed 를, ctx 가 취소되거나 제한 시간이 지나면 ctx.Err() 를 반환한다.
+// 대기열에 들어간 뒤 ctx 가 끝나면 envelope 은 나중에 전송될 수 있다.
+func (conn *GrpcConnection) SendContext(ctx context.Context, payload []byte, protocol string) error {
+
+	result := make(chan error, 1)
+
+	envelope := &pb.Envelope{Type: pb.Envelope_NORMAL, Protocol: protocol, Payload: payload}
+	err := conn.deliver(ctx, envelope, func(interface{}) {
+		result <- nil
+	}, func(err error) {
+		result <- err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-conn.closed:
+		return ErrConnClosed
+	}
+}
+
+// SendSync 는 payload 를 보내고 stream 에 쓸 때까지 기다린다.
+func (conn *GrpcConnection) SendSync(payload []byte, protocol string) error {
+	return conn.SendContext(context.Background(), payload, protocol)
+}
+
+// SendAsync 는 payload 를 보내고 전송 결과를 기다릴 수 있는 SendFuture 를 반환한다.
+// 대기열이 가득 차 있으면 자리가 날 때까지 기다린다.
+func (conn *GrpcConnection) SendAsync(payload []byte, protocol string) *SendFuture {
+
+	future := newSendFuture()
+
+	conn.send(&pb.Envelope{Type: pb.Envelope_NORMAL, Protocol: protocol, Payload: payload}, func(interface{}) {
+		future.resolve(nil)
+	}, future.resolve)
+
+	return future
+}
+
+// Request 는 payload 를 보내고 상대방이 Message.Respond 로 보낸 응답을 기다린다.
+// ctx 가 취소되거나 제한 시간이 지나면 ctx.Err() 를, 응답 전에 connection 이 닫히면 ErrConnClosed 를 반환한다.
+func (conn *GrpcConnection) Request(ctx context.Context, payload []byte, protocol string) ([]byte, error) {
+
+	requestID := atomic.AddUint64(&conn.requestSeq, 1)
+
+	result, err := conn.pending.add(requestID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.pending.remove(requestID)
+
+	envelope := &pb.Envelope{Type: pb.Envelope_NORMAL, Protocol: protocol, Payload: payload, RequestId: requestID}
+	err = conn.enqueueShared(ctx, envelope, nil, func(err error) {
+		conn.pending.resolve(requestID, nil, err)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-result:
+		return r.payload, r.err
+	}
+}
+
+// Reply 는 requestID 의 request 에 대한 응답을 보낸다.
+func (conn *GrpcConnection) Reply(requestID uint64, payload []byte, protocol string, successCallBack func(interface{}), errCallBack func(error)) {
+	conn.send(&pb.Envelope{Type: pb.Envelope_RESPONSE, Protocol: protocol, Payload: payload, RequestId: requestID}, successCallBack, errCallBack)
+}
+
+// Forward 는 다른 peer 가 작성하고 서명한 envelope 을 그대로 감싸서 전달한다.
+// 받는 쪽은 VerifyOrigin 으로 작성자를 확인하므로 중간 peer 를 거쳐도 작성자의 서명이 유지된다.
+func (conn *GrpcConnection) Forward(envelope *pb.Envelope, successCallBack func(interface{}), errCallBack func(error)) {
+
+	if envelope.Encrypted || envelope.Compression != pb.Compression_NO_COMPRESSION || envelope.Type != pb.Envelope_NORMAL {
+		if errCallBack != nil {
+			go errCallBack(ErrInvalidRelayMessage)
+		}
+		return
+	}
+
+	payload, err := proto.Marshal(envelope)
+	if err != nil {
+		if errCallBack != nil {
+			go errCallBack(err)
+		}
+		return
+	}
+
+	conn.send(&pb.Envelope{Type: pb.Envelope_RELAY, Protocol: envelope.Protocol, Payload: payload}, successCallBack, errCallBack)
+}
+
+// RotateKey 는 자신의 key 를 newKey 로 바꾸고 상대방에게 알린다.
+// ROTATE_KEY envelope 은 이전 key 로 서명하고, 그 뒤에 보내는 envelope 은 newSigner 로 서명한다.
+func (conn *GrpcConnection) RotateKey(newKey Key, newSigner Signer, successCallBack func(interface{}), errCallBack func(error)) {
 
 	conn.Lock()
 	defer conn.Unlock()
 
-	signedEnvelope, err := conn.build(protocol, payload)
+	newKeyBytes, err := newKey.ToByte()
+	if err != nil {
+		if errCallBack != nil {
+			go errCallBack(err)
+		}
+		return
+	}
+
+	payload, err := buildKeyRotation(conn.localKeyBytes, newKeyBytes, newSigner)
+	if err != nil {
+		if errCallBack != nil {
+			go errCallBack(err)
+		}
+		return
+	}
+
+	// lane 마다 순서가 바뀔 수 있으므로 이전 key 로 서명한 envelope 을 모두 보낸 뒤에 rotation envelope 을 보낸다.
+	if err := conn.waitIdle(context.Background()); err != nil {
+		if errCallBack != nil {
+			go errCallBack(err)
+		}
+		return
+	}
+
+	written := make(chan error, 1)
+	err = conn.enqueue(context.Background(), &pb.Envelope{Type: pb.Envelope_ROTATE_KEY, Payload: payload}, func(interface{}) {
+		written <- nil
+	}, func(err error) {
+		written <- err
+	})
+
+	if err != nil {
+		if errCallBack != nil {
+			go errCallBack(err)
+		}
+		return
+	}
+
+	// 마지막 이전 key envelope 을 쓰는 중에도 대기열은 빌 수 있고, lane 의 credit 에 따라 다른 lane 이 먼저 전송될 수 있다.
+	// rotation envelope 을 쓸 때까지 lock 을 잡고 있어야 새 key 로 서명한 envelope 이 대기열에 들어가지 않는다.
+	select {
+	case err = <-written:
+	case <-conn.closed:
+		err = ErrConnClosed
+	}
 
 	if err != nil {
-		go errCallBack(errors.New(fmt.Sprintf("fail to sign envelope [%s]", err.Error())))
+		if errCallBack != nil {
+			go errCallBack(err)
+		}
 		return
 	}
 
+	conn.Signer = newSigner
+	conn.localKeyBytes = newKeyBytes
+
+	if successCallBack != nil {
+		go successCallBack("")
+	}
+}
+
+// OnKeyRotation 은 상대방의 key 가 바뀐 뒤 호출할 listener 를 등록한다.
+func (conn *GrpcConnection) OnKeyRotation(listener KeyRotationListener) {
+
+	conn.rotationLock.Lock()
+	defer conn.rotationLock.Unlock()
+
+	conn.rotationListeners = append(conn.rotationListeners, listener)
+}
+
+func (conn *GrpcConnection) send(envelope *pb.Envelope, successCallBack func(interface{}), errCallBack func(error)) {
+
+	err := conn.deliver(context.Background(), envelope, successCallBack, errCallBack)
+
+	if err != nil && errCallBack != nil {
+		go errCallBack(err)
+	}
+}
+
+// enqueueShared 는 read lock 을 잡고 enqueue 한다. 여러 goroutine 이 동시에 서명할 수 있다.
+func (conn *GrpcConnection) enqueueShared(ctx context.Context, envelope *pb.Envelope, successCallBack func(interface{}), errCallBack func(error)) error {
+
+	if !isControl(envelope) {
+		if err := conn.throttleSend(ctx, envelope.Protocol, len(envelope.Payload)); err != nil {
+			return err
+		}
+	}
+
+	conn.RLock()
+	defer conn.RUnlock()
+
+	return conn.enqueue(ctx, envelope, successCallBack, errCallBack)
+}
+
+// enqueue 는 envelope 에 서명해서 전송 대기열에 넣는다. conn 의 lock 을 잡은 상태에서 호출해야 한다.
+// 대기열이 가득 차 있으면 자리가 나거나, connection 이 닫히거나, ctx 가 끝날 때까지 기다린다.
+func (conn *GrpcConnection) enqueue(ctx context.Context, envelope *pb.Envelope, successCallBack func(interface{}), errCallBack func(error)) error {
+
+	if atomic.LoadInt32(&conn.draining) == 1 {
+		return ErrConnClosed
+	}
+
+	return conn.enqueueControl(ctx, envelope, successCallBack, errCallBack)
+}
+
+// enqueueControl 은 CloseGracefully 중에도 enqueue 한다. GOAWAY 처럼 연결을 끝내는 메세지에만 사용한다.
+func (conn *GrpcConnection) enqueueControl(ctx context.Context, envelope *pb.Envelope, successCallBack func(interface{}), errCallBack func(error)) error {
+
+	if conn.toDie() {
+		return ErrConnClosed
+	}
+
+	priority := conn.priorityOf(envelope)
+
+	signedEnvelope, err := conn.build(envelope, priority)
+
+	if err != nil {
+		return errors.New(fmt.Sprintf("fail to sign envelope [%s]", err.Error()))
+	}
+
 	m := &innerMessage{
 		Envelope:  signedEnvelope,
 		OnErr:     errCallBack,
 		OnSuccess: successCallBack,
 	}
 
-	conn.outChannl <- m
+	select {
+	case conn.outbound.lane(priority) <- m:
+		return nil
+	case <-conn.closed:
+		return ErrConnClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (conn *GrpcConnection) build(protocol string, payload []byte) (*pb.Envelope, error) {
+// build 는 type, protocol, payload 가 채워진 envelope 에 작성자 key, lane, sequence number, timestamp 를 채우고 서명한다.
+func (conn *GrpcConnection) build(envelope *pb.Envelope, priority Priority) (*pb.Envelope, error) {
+
+	envelope.Pubkey = conn.localKeyBytes
+	envelope.Lane = uint32(priority)
+	envelope.Seq = atomic.AddUint64(&conn.seqs[priority], 1)
+	envelope.Timestamp = time.Now().UnixNano()
 
-	sig, err := conn.Sign(payload)
+	// signature 를 제외한 envelope 전체에 서명한다.
+	sig, err := conn.Sign(SigningBytes(envelope))
 	if err != nil {
 		return nil, err
 	}
 
-	envelope := &pb.Envelope{}
 	envelope.Signature = sig
-	envelope.Payload = payload
-	envelope.Type = pb.Envelope_NORMAL
-	envelope.Protocol = protocol
-	envelope.Pubkey = []byte("key")
+
+	// 서명은 압축하기 전의 payload 에 대해 한다. 압축해도 줄지 않으면 그대로 보낸다.
+	if algorithm := conn.session.compression(); algorithm != pb.Compression_NO_COMPRESSION && len(envelope.Payload) >= conn.compression.Threshold {
+		compressed, err := compress(algorithm, envelope.Payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(compressed) < len(envelope.Payload) {
+			envelope.Payload = compressed
+			envelope.Compression = algorithm
+		}
+	}
+
+	// 서명은 평문에 대해 하고 암호화는 그 뒤에 한다.
+	if conn.session.Encrypted() {
+		conn.session.seal(envelope)
+	}
 
 	return envelope, nil
 }
 
+// decrypt 는 암호화된 envelope 의 payload 를 복호화한다.
+// 암호화 session 이 있는 연결에서 암호화되지 않은 envelope 이나 복호화에 실패한 envelope 은 받아들이지 않는다.
+func (conn *GrpcConnection) decrypt(envelope *pb.Envelope) bool {
+
+	if !conn.session.Encrypted() {
+		return !envelope.Encrypted
+	}
+
+	if !envelope.Encrypted {
+		return false
+	}
+
+	if err := conn.session.open(envelope); err != nil {
+		iLogger.Infof(nil, "[Bifrost] %s", err.Error())
+		return false
+	}
+
+	return true
+}
+
+// decompress 는 압축된 envelope 의 payload 를 서명 검증 전에 푼다.
+// handshake 에서 합의하지 않은 방식이거나 압축을 푼 크기가 제한을 넘는 envelope 은 받아들이지 않는다.
+func (conn *GrpcConnection) decompress(envelope *pb.Envelope) bool {
+
+	if envelope.Compression == pb.Compression_NO_COMPRESSION {
+		return true
+	}
+
+	if !conn.session.supportsCompression(envelope.Compression) {
+		iLogger.Infof(nil, "[Bifrost] %s", ErrCompressionNotNegotiated.Error())
+		return false
+	}
+
+	payload, err := decompress(envelope.Compression, envelope.Payload, conn.compression.MaxDecompressedSize)
+	if err != nil {
+		iLogger.Infof(nil, "[Bifrost] Fail to decompress envelope [%s]", err.Error())
+		return false
+	}
+
+	envelope.Payload = payload
+	envelope.Compression = pb.Compression_NO_COMPRESSION
+
+	return true
+}
+
 func (conn *GrpcConnection) Verify(envelope *pb.Envelope) bool {
-	flag, err := conn.Crypto.Verify(conn.peerKey, envelope.Signature, envelope.Payload)
+
+	peer := conn.identity()
+
+	// 연결된 peer 가 직접 보낸 envelope 은 peer 의 key 로 서명되어 있어야 한다.
+	if !bytes.Equal(envelope.Pubkey, peer.keyBytes) {
+		return false
+	}
+
+	flag, err := conn.Crypto.Verify(peer.key, envelope.Signature, SigningBytes(envelope))
 
 	if err != nil {
 		iLogger.Infof(nil, "[Bifrost] %s", err.Error())
@@ -168,28 +720,79 @@ func (conn *GrpcConnection) writeStream() {
 
 	for !conn.toDie() {
 
-		select {
+		m, ok := conn.outbound.poll()
 
-		case m := <-conn.outChannl:
-			err := conn.streamWrapper.Send(m.Envelope)
-			if err != nil {
-				if m.OnErr != nil {
-					go m.OnErr(err)
-				}
-			} else {
-				if m.OnSuccess != nil {
-					go m.OnSuccess("")
-				}
+		if !ok {
+			select {
+			case m = <-conn.outbound.lanes[PriorityHigh]:
+			case m = <-conn.outbound.lanes[PriorityNormal]:
+			case m = <-conn.outbound.lanes[PriorityLow]:
+			case stop := <-conn.stopChannel:
+				conn.stopChannel <- stop
+				return
+			}
+		}
+
+		// Send 가 반환한 뒤에는 envelope 을 읽지 않는다. 받는 쪽이 같은 envelope 을 바꿀 수 있다.
+		protocol, size := m.Envelope.Protocol, len(m.Envelope.Payload)
+
+		err := conn.streamWrapper.Send(m.Envelope)
+		if err != nil {
+			conn.stats.sendFailed()
+			if m.OnErr != nil {
+				go m.OnErr(err)
+			}
+		} else {
+			conn.stats.sent(protocol, size)
+			if m.OnSuccess != nil {
+				go m.OnSuccess("")
 			}
-		case stop := <-conn.stopChannel:
-			conn.stopChannel <- stop
-			return
 		}
+
+		conn.outbound.notifyIfIdle()
+	}
+}
+
+// priorityOf 는 envelope 을 넣을 lane 의 우선순위를 정한다. 제어 메세지는 항상 PriorityHigh 를 사용한다.
+func (conn *GrpcConnection) priorityOf(envelope *pb.Envelope) Priority {
+
+	if isControl(envelope) {
+		return PriorityHigh
+	}
+
+	if priority, ok := conn.priorities[envelope.Protocol]; ok && priority >= 0 && priority < priorityCount {
+		return priority
 	}
+
+	return PriorityNormal
+}
+
+// waitIdle 은 전송 대기열이 빌 때까지 기다린다. write lock 을 잡은 상태에서 호출해야 대기열에 더 들어오는 메세지가 없다.
+func (conn *GrpcConnection) waitIdle(ctx context.Context) error {
+
+	// 이전에 보낸 신호는 버린다.
+	select {
+	case <-conn.outbound.idle:
+	default:
+	}
+
+	for conn.outbound.len() != 0 {
+		select {
+		case <-conn.outbound.idle:
+		case <-conn.closed:
+			return ErrConnClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
 }
 
 func (conn *GrpcConnection) readStream(errChan chan error) {
 
+	defer close(conn.readDone)
+
 	defer func() {
 		recover()
 	}()
@@ -211,7 +814,172 @@ func (conn *GrpcConnection) readStream(errChan chan error) {
 	}
 }
 
+// checkReplay 는 envelope 이 보내진 lane 의 replay 검사를 한다.
+// 서명이 확인된 envelope 만 검사해야 위조된 sequence number 로 window 를 밀어낼 수 없다.
+func (conn *GrpcConnection) checkReplay(envelope *pb.Envelope) error {
+
+	if envelope.Lane >= priorityCount {
+		return ErrMessageOutOfWindow
+	}
+
+	return conn.replayGuards[envelope.Lane].check(envelope.Seq, envelope.Timestamp, time.Now())
+}
+
+// handleInvalidMessage 는 서명 검증에 실패한 envelope 을 정책에 따라 처리한다.
+// 연결을 끊어야 하는 경우 에러를 반환한다.
+func (conn *GrpcConnection) handleInvalidMessage(envelope *pb.Envelope) error {
+
+	// 정책과 관계없이 평판에는 기록한다.
+	conn.report(EventInvalidSignature)
+
+	policy := conn.invalidMessagePolicy
+
+	if policy.Action == InvalidMessageDrop {
+		return nil
+	}
+
+	conn.serveError(&InvalidMessageError{KeyID: conn.GetID(), Protocol: envelope.Protocol})
+
+	if policy.Action != InvalidMessageDisconnect {
+		return nil
+	}
+
+	maxFailures := policy.MaxFailures
+	if maxFailures == 0 {
+		maxFailures = defaultMaxInvalidMessages
+	}
+
+	if conn.invalidMessages.add(time.Now()) >= maxFailures {
+		return ErrTooManyInvalidMessages
+	}
+
+	return nil
+}
+
+// serve 는 검증된 envelope 을 handler 에게 전달한다. 전달(relay)된 envelope 은 작성자의 서명을 확인한 뒤 원래 envelope 을 전달한다.
+func (conn *GrpcConnection) serve(envelope *pb.Envelope) {
+
+	var ack *deliveryAck
+	if envelope.AckId != 0 {
+		var ok bool
+		if ack, ok = conn.acceptAck(envelope); !ok {
+			return
+		}
+	}
+
+	if envelope.Type != pb.Envelope_RELAY {
+		conn.dispatch(Message{Envelope: envelope, Conn: conn, Data: envelope.Payload, Origin: conn.GetPeerKey(), requestID: envelope.RequestId, ack: ack})
+		return
+	}
+
+	origin, inner, err := conn.unwrap(envelope)
+	if err != nil {
+		conn.serveError(err)
+		conn.report(EventMalformedMessage)
+		return
+	}
+
+	// 작성자의 서명은 유지되므로 중간 peer 는 같은 envelope 을 몇 번이든 다시 전달할 수 있다.
+	if err := conn.relayGuard.check(origin.ID(), SigningBytes(inner), inner.Timestamp, time.Now()); err != nil {
+		conn.stats.drop(1)
+		conn.serveError(err)
+		conn.report(EventProtocolViolation)
+		return
+	}
+
+	conn.dispatch(Message{Envelope: inner, Conn: conn, Data: inner.Payload, Origin: origin})
+}
+
+// dispatch 는 Dispatcher 로 handler 를 호출한다. 같은 key 의 메세지는 받은 순서대로 처리된다.
+func (conn *GrpcConnection) dispatch(msg Message) {
+
+	handler := conn.handler
+
+	conn.dispatcher.Dispatch(conn.dispatchKey(msg), func() {
+		handler.ServeRequest(msg)
+
+		if msg.ack != nil {
+			conn.sendAck(msg.ack)
+		}
+	})
+}
+
+func protocolKey(msg Message) string {
+	return msg.Envelope.Protocol
+}
+
+// unwrap 은 relay envelope 에 담긴 원래 envelope 을 꺼내고 작성자의 서명을 검증한다.
+// 원래 envelope 의 sequence number 는 작성자와의 연결 기준이므로 replay 검사는 serve 에서 relayGuard 로 한다.
+func (conn *GrpcConnection) unwrap(envelope *pb.Envelope) (Key, *pb.Envelope, error) {
+
+	inner := &pb.Envelope{}
+	if err := proto.Unmarshal(envelope.Payload, inner); err != nil {
+		return nil, nil, ErrInvalidRelayMessage
+	}
+
+	if inner.Encrypted || inner.Type != pb.Envelope_NORMAL {
+		return nil, nil, ErrInvalidRelayMessage
+	}
+
+	origin, err := VerifyOrigin(inner, conn.Crypto)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return origin, inner, nil
+}
+
+// rotatePeerKey 는 이전 key 로 서명이 확인된 ROTATE_KEY envelope 의 proof 를 검증하고 peer 의 key 를 바꾼다.
+func (conn *GrpcConnection) rotatePeerKey(envelope *pb.Envelope) {
+
+	old := conn.identity()
+
+	rotated, err := verifyKeyRotation(envelope, old.keyBytes, conn.Crypto)
+	if err != nil {
+		conn.serveError(err)
+		conn.report(EventProtocolViolation)
+		return
+	}
+
+	conn.peer.Store(rotated)
+
+	iLogger.Infof(nil, "[Bifrost] Peer key rotated [%s] -> [%s]", old.key.ID(), rotated.key.ID())
+
+	conn.rotationLock.Lock()
+	listeners := make([]KeyRotationListener, len(conn.rotationListeners))
+	copy(listeners, conn.rotationListeners)
+	conn.rotationLock.Unlock()
+
+	for _, listener := range listeners {
+		listener(conn, old.key.ID())
+	}
+}
+
+// report 는 peer 의 잘못된 행동을 평판에 기록하고, 차단되면 handler 에게 알린 뒤 연결을 끊는다.
+func (conn *GrpcConnection) report(event ReputationEvent) {
+
+	if conn.reputation == nil || !conn.reputation.Report(conn.GetID(), conn.ip.IP, event) {
+		return
+	}
+
+	conn.serveError(ErrPeerBanned)
+	conn.close(ErrPeerBanned)
+}
+
+func (conn *GrpcConnection) serveError(err error) {
+	iLogger.Infof(nil, "[Bifrost] %s", err.Error())
+
+	if conn.handler != nil {
+		conn.handler.ServeError(conn, err)
+	}
+}
+
 func (conn *GrpcConnection) Close() {
+	conn.close(ErrConnClosed)
+}
+
+// close 는 연결을 끊고 cause 를 끊긴 원인으로 기록한다. 처음 호출한 경우에만 기록된다.
+func (conn *GrpcConnection) close(cause error) {
 
 	if conn.toDie() {
 		return
@@ -223,39 +991,216 @@ func (conn *GrpcConnection) Close() {
 		return
 	}
 
+	conn.err = cause
+
+	// 대기열이 비기를 기다리며 read lock 을 잡고 있는 sender 를 먼저 깨운다.
+	close(conn.closed)
+
 	conn.stopChannel <- struct{}{}
 	conn.Lock()
 
 	conn.streamWrapper.Close()
+	conn.failQueued(ErrConnClosed)
 
 	conn.Unlock()
+
+	conn.pending.closeAll(ErrConnClosed)
+	conn.transfers.closeAll(ErrConnClosed)
+	conn.acks.closeAll(ErrConnClosed)
+
+	if conn.ownedPool != nil {
+		conn.ownedPool.Stop()
+	}
+
+	conn.setState(ConnClosed)
+}
+
+// failQueued 는 전송하지 못하고 대기열에 남은 메세지의 errCallBack 을 호출한다.
+// write lock 을 잡은 상태에서 호출해야 대기열에 더 들어오는 메세지가 없다.
+func (conn *GrpcConnection) failQueued(err error) {
+
+	for _, lane := range conn.outbound.lanes {
+	drain:
+		for {
+			select {
+			case m := <-lane:
+				conn.stats.drop(1)
+				if m.OnErr != nil {
+					go m.OnErr(err)
+				}
+			default:
+				break drain
+			}
+		}
+	}
 }
 
 func (conn *GrpcConnection) Start() error {
 
+	// 더 이상 응답을 받을 수 없으므로 기다리는 request 를 끝낸다.
+	defer conn.pending.closeAll(ErrConnClosed)
+	defer conn.transfers.closeAll(ErrConnClosed)
+	defer conn.acks.closeAll(ErrConnClosed)
+
+	conn.setState(ConnActive)
+
 	errChan := make(chan error, 1)
 
 	go conn.readStream(errChan)
 	go conn.writeStream()
+	go conn.serveInbound()
+
+	// heartbeat 를 사용하지 않으면 nil channel 이므로 선택되지 않는다.
+	var heartbeatTick <-chan time.Time
+	if conn.heartbeat.interval > 0 {
+		ticker := time.NewTicker(conn.heartbeat.interval)
+		defer ticker.Stop()
+		heartbeatTick = ticker.C
+	}
 
 	for !conn.toDie() {
 		select {
 		case stop := <-conn.stopChannel:
 			conn.stopChannel <- stop
-			return nil
+			return conn.stopErr()
 		case err := <-errChan:
+			// GOAWAY 를 받은 상대방이 연결을 끊은 것이다. 연결은 CloseGracefully 가 끊는다.
+			if atomic.LoadInt32(&conn.draining) == 1 {
+				return nil
+			}
+			conn.close(err)
 			return err
+		case now := <-heartbeatTick:
+			id, err := conn.heartbeat.ping(now)
+			if err != nil {
+				conn.serveError(err)
+				conn.close(err)
+				return err
+			}
+			go conn.send(&pb.Envelope{Type: pb.Envelope_PING, Payload: heartbeatPayload(id)}, nil, nil)
 		case message := <-conn.readChannel:
-			if conn.Verify(message) {
-				if conn.handler != nil {
-					m := Message{Envelope: message, Conn: conn, Data: message.Payload}
-					conn.handler.ServeRequest(m)
+			// 복호화하거나 압축을 풀기 전의 크기를 집계한다.
+			size := len(message.Payload)
+
+			if conn.decrypt(message) && conn.decompress(message) && conn.Verify(message) {
+				// 서명이 확인된 envelope 만 replay 검사에 반영해야 위조된 sequence number 로 window 를 밀어낼 수 없다.
+				if err := conn.checkReplay(message); err != nil {
+					conn.stats.drop(1)
+					conn.serveError(err)
+					conn.report(EventProtocolViolation)
+					continue
+				}
+
+				conn.stats.received(message.Protocol, size)
+
+				if message.Type == pb.Envelope_GOAWAY {
+					err := receiveGoAway(message)
+					conn.serveError(err)
+					conn.close(err)
+					return err
+				}
+
+				if message.Type == pb.Envelope_PING {
+					go conn.send(&pb.Envelope{Type: pb.Envelope_PONG, Payload: message.Payload}, nil, nil)
+					continue
+				}
+
+				if message.Type == pb.Envelope_PONG {
+					conn.heartbeat.pong(heartbeatID(message), time.Now())
+					continue
+				}
+
+				if message.Type == pb.Envelope_ROTATE_KEY {
+					conn.rotatePeerKey(message)
+					continue
+				}
+
+				// handler 안에서 보낸 Request 의 응답도 받을 수 있도록 handler 를 호출하는 serveInbound 를 거치지 않는다.
+				if message.Type == pb.Envelope_RESPONSE {
+					// 이미 제한 시간이 지나 기다리지 않는 응답은 버린다.
+					if !conn.pending.resolve(message.RequestId, message.Payload, nil) {
+						conn.stats.drop(1)
+						iLogger.Infof(nil, "[Bifrost] Drop response for unknown request [%d]", message.RequestId)
+					}
+					continue
+				}
+
+				if message.Type == pb.Envelope_CHUNK_ACK {
+					conn.receiveChunkAck(message)
+					continue
+				}
+
+				if message.Type == pb.Envelope_ACK {
+					conn.receiveAck(message)
+					continue
+				}
+
+				// 받기 제한을 기다리는 동안에도 제어 메세지는 처리할 수 있도록 따로 처리한다.
+				select {
+				case conn.inbound <- inboundEnvelope{envelope: message, size: size}:
+				case <-conn.closed:
 				}
 			} else {
-				// todo: verify 결과 false인 경우
+				conn.stats.verifyFailed()
+
+				if err := conn.handleInvalidMessage(message); err != nil {
+					conn.close(err)
+					return err
+				}
 			}
 		}
 	}
 
+	return conn.stopErr()
+}
+
+// stopErr 는 다른 곳에서 연결을 끊어 Start 가 끝날 때 반환할 error 이다.
+// 직접 끊은 경우에는 nil 을, 차단처럼 다른 원인으로 끊긴 경우에는 Err 와 같은 원인을 반환한다.
+func (conn *GrpcConnection) stopErr() error {
+
+	if err := conn.Err(); err != ErrConnClosed {
+		return err
+	}
+
 	return nil
 }
+
+// serveInbound 는 제어 메세지가 아닌 메세지를 받은 순서대로 받기 제한을 기다린 뒤 처리한다.
+// 대기열이 가득 차면 read loop 가 멈추므로 stream 을 더 읽지 않고, 상대방은 gRPC 의 flow control 로 보내기를 멈추게 된다.
+func (conn *GrpcConnection) serveInbound() {
+
+	for {
+		select {
+		case in := <-conn.inbound:
+			// 연결이 끊겨 기다리지 못한 메세지도 이미 받은 메세지이므로 처리한다.
+			conn.throttleReceive(in.envelope.Protocol, in.size)
+			conn.receive(in.envelope)
+		case <-conn.closed:
+			for {
+				select {
+				case in := <-conn.inbound:
+					conn.receive(in.envelope)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (conn *GrpcConnection) receive(message *pb.Envelope) {
+
+	if conn.handler == nil {
+		if message.AckId != 0 {
+			conn.sendAck(&deliveryAck{id: message.AckId, code: AckUnhandled, reason: "no handler"})
+		}
+		return
+	}
+
+	if message.Type == pb.Envelope_CHUNK {
+		conn.receiveChunk(message)
+		return
+	}
+
+	conn.serve(message)
+}
@@ -72,7 +72,7 @@ func TestGrpcConnection_Send(t *testing.T) {
 	crypto.Signer.(*mocks.MockECDSASigner).KeyID = keyOpts.PubKey.ID()
 	crypto.Signer.(*mocks.MockECDSASigner).KeyDirPath = "./.test_private_key"
 
-	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, mockStreamWrapper, crypto)
+	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, keyOpts.PubKey, mockStreamWrapper, crypto, bifrost.ConnOpts{}, nil)
 	assert.NoError(t, err)
 
 	mockStreamWrapper.SendCallBack = func(envelope *pb.Envelope) {
@@ -94,13 +94,485 @@ func TestGrpcConnection_Send(t *testing.T) {
 	conn.Send([]byte("jun"), "test1", nil, nil)
 }
 
+func TestGrpcConnection_Verify_whenEnvelopeTampered(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	crypto := mocks.NewMockCryptoWithKey(keyOpts.PriKey)
+
+	sent := make(chan *pb.Envelope, 1)
+	mockStreamWrapper := mocks.MockStreamWrapper{SendCallBack: func(envelope *pb.Envelope) {
+		sent <- envelope
+	}}
+
+	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, keyOpts.PubKey, mockStreamWrapper, crypto, bifrost.ConnOpts{}, nil)
+	assert.NoError(t, err)
+
+	go conn.Start()
+	defer conn.Close()
+
+	conn.Send([]byte("jun"), "test1", nil, nil)
+	envelope := <-sent
+	grpcConn := conn.(*bifrost.GrpcConnection)
+	assert.True(t, grpcConn.Verify(envelope))
+
+	tampers := map[string]func(e *pb.Envelope){
+		"payload":   func(e *pb.Envelope) { e.Payload = []byte("jun2") },
+		"pubkey":    func(e *pb.Envelope) { e.Pubkey = []byte("other key") },
+		"protocol":  func(e *pb.Envelope) { e.Protocol = "test2" },
+		"type":      func(e *pb.Envelope) { e.Type = pb.Envelope_RESPONSE_PEERINFO },
+		"seq":       func(e *pb.Envelope) { e.Seq++ },
+		"timestamp": func(e *pb.Envelope) { e.Timestamp++ },
+		"requestId": func(e *pb.Envelope) { e.RequestId++ },
+		"ackId":     func(e *pb.Envelope) { e.AckId++ },
+		"lane":      func(e *pb.Envelope) { e.Lane++ },
+	}
+
+	for field, tamper := range tampers {
+		// when
+		tampered := *envelope
+		tamper(&tampered)
+
+		// then
+		assert.False(t, grpcConn.Verify(&tampered), field)
+	}
+}
+
+func TestGrpcConnection_Start_whenEnvelopeReplayed(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	crypto := mocks.NewMockCryptoWithKey(keyOpts.PriKey)
+	local, remote := mocks.NewMockStreamPipe()
+
+	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, keyOpts.PubKey, local, crypto, bifrost.ConnOpts{}, nil)
+	assert.NoError(t, err)
+
+	served := make(chan bifrost.Message, 2)
+	errs := make(chan error, 2)
+	conn.Handle(mocks.MockFuncHandler{
+		RequestFunc: func(message bifrost.Message) { served <- message },
+		ErrorFunc:   func(conn bifrost.Connection, err error) { errs <- err },
+	})
+
+	go conn.Start()
+	defer conn.Close()
+
+	envelope := newSignedEnvelope(t, keyOpts, 1)
+
+	// when
+	assert.NoError(t, remote.Send(envelope))
+	assert.NoError(t, remote.Send(envelope))
+
+	// then
+	assert.Equal(t, []byte("jun"), (<-served).Data)
+	assert.Equal(t, bifrost.ErrDuplicateMessage, <-errs)
+}
+
+func TestGrpcConnection_Start_whenInvalidSignature(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	otherKeyOpts := mocks.NewMockKeyOpts()
+	local, remote := mocks.NewMockStreamPipe()
+
+	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, keyOpts.PubKey, local, mocks.NewMockCrypto(), bifrost.ConnOpts{}, nil)
+	assert.NoError(t, err)
+
+	errs := make(chan error, 1)
+	conn.Handle(mocks.MockFuncHandler{
+		ErrorFunc: func(conn bifrost.Connection, err error) { errs <- err },
+	})
+
+	go conn.Start()
+	defer conn.Close()
+
+	// when
+	assert.NoError(t, remote.Send(newSignedEnvelope(t, otherKeyOpts, 1)))
+
+	// then
+	assert.Equal(t, &bifrost.InvalidMessageError{KeyID: keyOpts.PubKey.ID(), Protocol: "test1"}, <-errs)
+}
+
+func TestGrpcConnection_Start_whenInvalidMessagePolicyDisconnect(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	otherKeyOpts := mocks.NewMockKeyOpts()
+	local, remote := mocks.NewMockStreamPipe()
+
+	opts := bifrost.ConnOpts{
+		InvalidMessagePolicy: bifrost.InvalidMessagePolicy{
+			Action:      bifrost.InvalidMessageDisconnect,
+			MaxFailures: 2,
+		},
+	}
+
+	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, keyOpts.PubKey, local, mocks.NewMockCrypto(), opts, nil)
+	assert.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- conn.Start()
+	}()
+
+	// when
+	assert.NoError(t, remote.Send(newSignedEnvelope(t, otherKeyOpts, 1)))
+	assert.NoError(t, remote.Send(newSignedEnvelope(t, otherKeyOpts, 2)))
+
+	// then
+	assert.Equal(t, bifrost.ErrTooManyInvalidMessages, <-done)
+}
+
+func TestGrpcConnection_Start_whenPlaintextOnEncryptedConnection(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	local, remote := mocks.NewMockStreamPipe()
+
+	ephemeralKey, err := bifrost.NewEphemeralKey()
+	assert.NoError(t, err)
+	peerEphemeralKey, err := bifrost.NewEphemeralKey()
+	assert.NoError(t, err)
+	nonce, err := bifrost.NewNonce()
+	assert.NoError(t, err)
+
+	session := &bifrost.Session{}
+	err = session.EstablishKeys(ephemeralKey, peerEphemeralKey.PublicKey().Bytes(), nonce, nonce, true)
+	assert.NoError(t, err)
+
+	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, keyOpts.PubKey, local, mocks.NewMockCrypto(), bifrost.ConnOpts{}, session)
+	assert.NoError(t, err)
+
+	errs := make(chan error, 1)
+	conn.Handle(mocks.MockFuncHandler{
+		RequestFunc: func(message bifrost.Message) { t.Error("plaintext envelope should be dropped") },
+		ErrorFunc:   func(conn bifrost.Connection, err error) { errs <- err },
+	})
+
+	go conn.Start()
+	defer conn.Close()
+
+	// when
+	assert.NoError(t, remote.Send(newSignedEnvelope(t, keyOpts, 1)))
+
+	// then
+	assert.Equal(t, &bifrost.InvalidMessageError{KeyID: keyOpts.PubKey.ID(), Protocol: "test1"}, <-errs)
+}
+
+func newSignedEnvelope(t *testing.T, keyOpts bifrost.KeyOpts, seq uint64) *pb.Envelope {
+	pubKeyBytes, err := keyOpts.PubKey.ToByte()
+	assert.NoError(t, err)
+
+	envelope := &pb.Envelope{Payload: []byte("jun"), Pubkey: pubKeyBytes, Protocol: "test1", Type: pb.Envelope_NORMAL, Seq: seq, Timestamp: time.Now().UnixNano()}
+
+	sig, err := mocks.NewMockCryptoWithKey(keyOpts.PriKey).Sign(bifrost.SigningBytes(envelope))
+	assert.NoError(t, err)
+	envelope.Signature = sig
+
+	return envelope
+}
+
+func TestGrpcConnection_Start_whenPeerBanned(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	otherKeyOpts := mocks.NewMockKeyOpts()
+	local, remote := mocks.NewMockStreamPipe()
+
+	reputation := bifrost.NewReputation(bifrost.ReputationOpts{Threshold: -40})
+	opts := bifrost.ConnOpts{
+		InvalidMessagePolicy: bifrost.InvalidMessagePolicy{Action: bifrost.InvalidMessageDrop},
+		Reputation:           reputation,
+	}
+
+	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, keyOpts.PubKey, local, mocks.NewMockCrypto(), opts, nil)
+	assert.NoError(t, err)
+
+	errs := make(chan error, 1)
+	conn.Handle(mocks.MockFuncHandler{
+		ErrorFunc: func(conn bifrost.Connection, err error) { errs <- err },
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- conn.Start()
+	}()
+
+	// when
+	assert.NoError(t, remote.Send(newSignedEnvelope(t, otherKeyOpts, 1)))
+	assert.NoError(t, remote.Send(newSignedEnvelope(t, otherKeyOpts, 2)))
+
+	// then
+	assert.Equal(t, bifrost.ErrPeerBanned, <-errs)
+	assert.Equal(t, bifrost.ErrPeerBanned, <-done)
+	assert.True(t, reputation.IsBanned(keyOpts.PubKey.ID(), ""))
+}
+
+func TestGrpcConnection_Forward(t *testing.T) {
+	// given
+	originKeyOpts := mocks.NewMockKeyOpts()
+	relayConn, receiverConn := newTestConnPair(t, bifrost.ConnOpts{})
+
+	served := make(chan bifrost.Message, 1)
+	receiverConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) { served <- message }})
+
+	go relayConn.Start()
+	go receiverConn.Start()
+	defer relayConn.Close()
+
+	// when
+	relayConn.Forward(newSignedEnvelope(t, originKeyOpts, 7), nil, nil)
+
+	// then
+	message := <-served
+	assert.Equal(t, []byte("jun"), message.Data)
+	assert.Equal(t, uint64(7), message.Envelope.Seq)
+	assert.Equal(t, originKeyOpts.PubKey.ID(), message.Origin.ID())
+}
+
+func TestGrpcConnection_Forward_whenReplayed(t *testing.T) {
+	// given
+	originKeyOpts := mocks.NewMockKeyOpts()
+	relayConn, receiverConn := newTestConnPair(t, bifrost.ConnOpts{})
+
+	served := make(chan bifrost.Message, 2)
+	errs := make(chan error, 2)
+	receiverConn.Handle(mocks.MockFuncHandler{
+		RequestFunc: func(message bifrost.Message) { served <- message },
+		ErrorFunc:   func(conn bifrost.Connection, err error) { errs <- err },
+	})
+
+	go relayConn.Start()
+	go receiverConn.Start()
+	defer relayConn.Close()
+
+	envelope := newSignedEnvelope(t, originKeyOpts, 1)
+	stale := newSignedEnvelope(t, originKeyOpts, 2)
+	stale.Timestamp = time.Now().Add(-2 * time.Minute).UnixNano()
+	sig, err := mocks.NewMockCryptoWithKey(originKeyOpts.PriKey).Sign(bifrost.SigningBytes(stale))
+	assert.NoError(t, err)
+	stale.Signature = sig
+
+	// when
+	relayConn.Forward(envelope, nil, nil)
+	<-served
+	relayConn.Forward(envelope, nil, nil)
+	replayErr := <-errs
+	relayConn.Forward(stale, nil, nil)
+	staleErr := <-errs
+
+	// then
+	assert.Equal(t, bifrost.ErrDuplicateMessage, replayErr)
+	assert.Equal(t, bifrost.ErrStaleMessage, staleErr)
+	assert.Len(t, served, 0)
+}
+
+func TestGrpcConnection_Forward_whenOriginSignatureInvalid(t *testing.T) {
+	// given
+	originKeyOpts := mocks.NewMockKeyOpts()
+	relayConn, receiverConn := newTestConnPair(t, bifrost.ConnOpts{})
+
+	errs := make(chan error, 1)
+	receiverConn.Handle(mocks.MockFuncHandler{
+		RequestFunc: func(message bifrost.Message) { t.Error("forged relay message should be dropped") },
+		ErrorFunc:   func(conn bifrost.Connection, err error) { errs <- err },
+	})
+
+	go relayConn.Start()
+	go receiverConn.Start()
+	defer relayConn.Close()
+
+	// relay 가 작성자의 payload 를 바꿔서 전달
+	envelope := newSignedEnvelope(t, originKeyOpts, 1)
+	envelope.Payload = []byte("forged")
+
+	// when
+	relayConn.Forward(envelope, nil, nil)
+
+	// then
+	assert.Equal(t, bifrost.ErrInvalidOriginSignature, <-errs)
+}
+
+func TestGrpcConnection_Forward_whenInvalidEnvelope(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	conn, _ := newTestConn(t, keyOpts, bifrost.ConnOpts{}, nil)
+
+	errs := make(chan error, 1)
+
+	// when
+	// errCallBack 이 없으면 실패를 알리지 않는다.
+	conn.Forward(&pb.Envelope{Type: pb.Envelope_RESPONSE}, nil, nil)
+	conn.Forward(&pb.Envelope{Type: pb.Envelope_RESPONSE}, nil, func(err error) { errs <- err })
+
+	// then
+	assert.Equal(t, bifrost.ErrInvalidRelayMessage, <-errs)
+	// nil errCallBack 을 호출하는 goroutine 이 있다면 실행될 시간을 준다.
+	time.Sleep(50 * time.Millisecond)
+}
+
+func TestGrpcConnection_RotateKey(t *testing.T) {
+	// given
+	newKeyOpts := mocks.NewMockKeyOpts()
+	senderConn, receiverConn := newTestConnPair(t, bifrost.ConnOpts{})
+	senderID := receiverConn.GetID()
+
+	store := bifrost.NewConnectionStore()
+	assert.NoError(t, store.AddConnection(receiverConn))
+
+	rotated := make(chan bifrost.ConnID, 1)
+	receiverConn.OnKeyRotation(func(conn bifrost.Connection, oldID bifrost.ConnID) { rotated <- oldID })
+
+	served := make(chan bifrost.Message, 1)
+	receiverConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) { served <- message }})
+
+	go senderConn.Start()
+	go receiverConn.Start()
+	defer senderConn.Close()
+
+	// when
+	senderConn.RotateKey(newKeyOpts.PubKey, mocks.NewMockCryptoWithKey(newKeyOpts.PriKey).Signer, nil, nil)
+	senderConn.Send([]byte("jun"), "test1", nil, nil)
+
+	// then
+	assert.Equal(t, senderID, <-rotated)
+	assert.Equal(t, newKeyOpts.PubKey.ID(), (<-served).Origin.ID())
+	assert.Equal(t, newKeyOpts.PubKey.ID(), receiverConn.GetID())
+
+	_, err := store.GetConnection(senderID)
+	assert.Equal(t, bifrost.ErrConnNotExist, err)
+	storedConn, err := store.GetConnection(newKeyOpts.PubKey.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, receiverConn, storedConn)
+}
+
+// slowStreamWrapper 는 stream 에 쓰는 데 시간이 걸리는 연결을 흉내낸다.
+type slowStreamWrapper struct {
+	bifrost.StreamWrapper
+	delay time.Duration
+}
+
+func (s slowStreamWrapper) Send(envelope *pb.Envelope) error {
+	time.Sleep(s.delay)
+	return s.StreamWrapper.Send(envelope)
+}
+
+func TestGrpcConnection_RotateKey_whenSendingConcurrently(t *testing.T) {
+	// given
+	senderKeyOpts := mocks.NewMockKeyOpts()
+	newKeyOpts := mocks.NewMockKeyOpts()
+	receiverKeyOpts := mocks.NewMockKeyOpts()
+	pipe, remote := mocks.NewMockStreamPipe()
+	local := slowStreamWrapper{StreamWrapper: pipe, delay: 2 * time.Millisecond}
+
+	opts := bifrost.ConnOpts{Priorities: map[string]bifrost.Priority{"bulk": bifrost.PriorityLow, "vote": bifrost.PriorityHigh}}
+
+	senderConn, err := bifrost.NewConnection("127.0.0.1:1234", nil, senderKeyOpts.PubKey, receiverKeyOpts.PubKey, local, mocks.NewMockCryptoWithKey(senderKeyOpts.PriKey), opts, nil)
+	assert.NoError(t, err)
+	receiverConn, err := bifrost.NewConnection("127.0.0.1:1235", nil, receiverKeyOpts.PubKey, senderKeyOpts.PubKey, remote, mocks.NewMockCryptoWithKey(receiverKeyOpts.PriKey), bifrost.ConnOpts{}, nil)
+	assert.NoError(t, err)
+
+	errs := make(chan error, 100)
+	done := make(chan struct{}, 1)
+	receiverConn.Handle(mocks.MockFuncHandler{
+		RequestFunc: func(message bifrost.Message) {
+			if message.Envelope.Protocol == "done" {
+				done <- struct{}{}
+			}
+		},
+		ErrorFunc: func(conn bifrost.Connection, err error) { errs <- err },
+	})
+
+	go senderConn.Start()
+	go receiverConn.Start()
+	defer senderConn.Close()
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, protocol := range []string{"bulk", "test1", "test1", "vote", "vote", "vote", "vote"} {
+		wg.Add(1)
+		go func(protocol string) {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					senderConn.SendSync([]byte("jun"), protocol)
+				}
+			}
+		}(protocol)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	// when
+	rotated := make(chan error, 1)
+	senderConn.RotateKey(newKeyOpts.PubKey, mocks.NewMockCryptoWithKey(newKeyOpts.PriKey).Signer, func(interface{}) {
+		rotated <- nil
+	}, func(err error) {
+		rotated <- err
+	})
+	assert.NoError(t, <-rotated)
+
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+	assert.NoError(t, senderConn.SendSync([]byte("jun"), "done"))
+	<-done
+
+	// then
+	// 새 key 로 서명한 envelope 이 rotation envelope 보다 먼저 도착하면 서명 검증에 실패한다.
+	assert.Len(t, errs, 0)
+	assert.Equal(t, newKeyOpts.PubKey.ID(), receiverConn.GetID())
+	assert.Equal(t, uint64(0), receiverConn.Stats().VerifyFailures)
+}
+
+func TestGrpcConnection_RotateKey_whenProofInvalid(t *testing.T) {
+	// given
+	newKeyOpts := mocks.NewMockKeyOpts()
+	otherKeyOpts := mocks.NewMockKeyOpts()
+	senderConn, receiverConn := newTestConnPair(t, bifrost.ConnOpts{})
+	senderID := receiverConn.GetID()
+
+	errs := make(chan error, 1)
+	receiverConn.Handle(mocks.MockFuncHandler{ErrorFunc: func(conn bifrost.Connection, err error) { errs <- err }})
+
+	go senderConn.Start()
+	go receiverConn.Start()
+	defer senderConn.Close()
+
+	// when
+	// 새 key 의 private key 가 아닌 다른 key 로 proof 를 만든다.
+	senderConn.RotateKey(newKeyOpts.PubKey, mocks.NewMockCryptoWithKey(otherKeyOpts.PriKey).Signer, nil, nil)
+
+	// then
+	assert.Equal(t, bifrost.ErrInvalidKeyRotation, <-errs)
+	assert.Equal(t, senderID, receiverConn.GetID())
+}
+
+func TestGrpcConnection_RotateKey_whenClosed(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	newKeyOpts := mocks.NewMockKeyOpts()
+	conn, _ := newTestConn(t, keyOpts, bifrost.ConnOpts{}, nil)
+	conn.Close()
+
+	errs := make(chan error, 1)
+
+	// when
+	// errCallBack 이 없으면 실패를 알리지 않는다.
+	conn.RotateKey(newKeyOpts.PubKey, mocks.NewMockCryptoWithKey(newKeyOpts.PriKey).Signer, nil, nil)
+	conn.RotateKey(newKeyOpts.PubKey, mocks.NewMockCryptoWithKey(newKeyOpts.PriKey).Signer, nil, func(err error) { errs <- err })
+
+	// then
+	assert.Equal(t, bifrost.ErrConnClosed, <-errs)
+	// nil errCallBack 을 호출하는 goroutine 이 있다면 실행될 시간을 준다.
+	time.Sleep(50 * time.Millisecond)
+}
+
 func TestGrpcConnection_GetPeerKey(t *testing.T) {
 	//given
 	keyOpts := mocks.NewMockKeyOpts()
 	mockStreamWrapper := mocks.MockStreamWrapper{}
 	crypto := mocks.NewMockCrypto()
 
-	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, mockStreamWrapper, crypto)
+	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, keyOpts.PubKey, mockStreamWrapper, crypto, bifrost.ConnOpts{}, nil)
 	assert.NoError(t, err)
 
 	go func() {
@@ -131,7 +603,7 @@ func TestGrpcConnection_Close(t *testing.T) {
 	}
 	crypto := mocks.NewMockCrypto()
 
-	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, mockStreamWrapper, crypto)
+	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, keyOpts.PubKey, mockStreamWrapper, crypto, bifrost.ConnOpts{}, nil)
 	assert.NoError(t, err)
 
 	go func() {
@@ -145,14 +617,130 @@ func TestGrpcConnection_Close(t *testing.T) {
 	wg.Wait()
 }
 
+func TestGrpcConnection_SendContext(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPair(t, bifrost.ConnOpts{})
+
+	wg := sync.WaitGroup{}
+	wg.Add(10)
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		wg.Done()
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// when
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			errs <- localConn.SendContext(context.Background(), []byte("hello"), "test")
+		}()
+	}
+
+	// then
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, <-errs)
+	}
+	wg.Wait()
+}
+
+func TestGrpcConnection_SendContext_whenQueueFull(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	conn, _ := newTestConn(t, keyOpts, bifrost.ConnOpts{}, nil)
+
+	// writeStream 이 동작하지 않으므로 대기열이 가득 찬다.
+	for i := 0; i < 200; i++ {
+		conn.Send([]byte("hello"), "test", nil, nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// when
+	err := conn.SendContext(ctx, []byte("hello"), "test")
+
+	// then
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestGrpcConnection_SendContext_whenClosed(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	conn, _ := newTestConn(t, keyOpts, bifrost.ConnOpts{}, nil)
+
+	for i := 0; i < 200; i++ {
+		conn.Send([]byte("hello"), "test", nil, nil)
+	}
+
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- conn.SendContext(context.Background(), []byte("hello"), "test")
+	}()
+
+	// when
+	conn.Close()
+
+	// then
+	assert.Equal(t, bifrost.ErrConnClosed, <-blocked)
+	assert.Equal(t, bifrost.ErrConnClosed, conn.SendContext(context.Background(), []byte("hello"), "test"))
+
+	sendErr := make(chan error, 1)
+	conn.Send([]byte("hello"), "test", nil, func(err error) {
+		sendErr <- err
+	})
+	assert.Equal(t, bifrost.ErrConnClosed, <-sendErr)
+}
+
 func TestGrpcConnection_GetIP(t *testing.T) {
 	keyOpts := mocks.NewMockKeyOpts()
 
 	mockStreamWrapper := mocks.MockStreamWrapper{}
 	crypto := mocks.NewMockCrypto()
 
-	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, mockStreamWrapper, crypto)
+	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, keyOpts.PubKey, mockStreamWrapper, crypto, bifrost.ConnOpts{}, nil)
 	assert.NoError(t, err)
 	ipAddr := conn.GetIP()
 	assert.Equal(t, bifrost.Address{IP: "127.0.0.1:1234"}, ipAddr)
 }
+
+func TestGrpcConnection_Send_whenLowLaneSaturated(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPairWithOpts(t, bifrost.ConnOpts{
+		Priorities: map[string]bifrost.Priority{"block": bifrost.PriorityLow, "vote": bifrost.PriorityHigh},
+	}, bifrost.ConnOpts{
+		ReplayWindow: 32,
+	}, nil)
+
+	served := make(chan struct{}, 300)
+	errs := make(chan error, 300)
+	remoteConn.Handle(mocks.MockFuncHandler{
+		RequestFunc: func(message bifrost.Message) { served <- struct{}{} },
+		ErrorFunc:   func(conn bifrost.Connection, err error) { errs <- err },
+	})
+
+	// writeStream 이 동작하기 전에 low lane 과 high lane 을 채운다.
+	for i := 0; i < 150; i++ {
+		localConn.Send([]byte("block"), "block", nil, nil)
+	}
+	for i := 0; i < 150; i++ {
+		localConn.Send([]byte("vote"), "vote", nil, nil)
+	}
+
+	// when
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// then
+	// high lane 이 low lane 을 앞질러도 lane 마다 sequence number 가 따로 증가하므로 replay window 를 벗어나지 않는다.
+	for i := 0; i < 300; i++ {
+		select {
+		case <-served:
+		case err := <-errs:
+			t.Fatal(err)
+		}
+	}
+}
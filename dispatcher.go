@@ -0,0 +1,154 @@
+package bifrost
+
+import (
+	"runtime"
+	"sync"
+)
+
+const defaultDispatchQueueSize = 64
+
+// Dispatcher 는 받은 메세지의 handler 호출을 실행한다.
+// 같은 key 의 작업은 받은 순서대로 실행해야 하며, 다른 key 의 작업은 동시에 실행할 수 있다.
+// 작업을 받을 수 없으면 Dispatch 는 기다려야 한다. 기다리는 동안 connection 은 더 읽지 않는다.
+type Dispatcher interface {
+	Dispatch(key string, task func())
+}
+
+// inlineDispatcher 는 connection 의 read loop 에서 바로 실행한다. 모든 메세지의 순서가 유지된다.
+type inlineDispatcher struct{}
+
+func (inlineDispatcher) Dispatch(key string, task func()) {
+	task()
+}
+
+// WorkerPool 은 key 마다 대기열을 두고 정해진 수의 worker 로 실행하는 Dispatcher 이다.
+// 같은 key 는 한 번에 하나씩 순서대로 실행하고, 다른 key 는 쉬는 worker 가 있으면 동시에 실행한다.
+// 여러 connection 이 하나의 WorkerPool 을 공유할 수 있다.
+type WorkerPool struct {
+	lock      sync.Mutex
+	queueSize int
+	// 작업이 남은 key 의 대기열. 대기열이 비면 지운다.
+	queues map[string]*keyQueue
+	// 실행할 차례를 기다리는 대기열. worker 는 한 번에 작업 하나를 실행하고 대기열을 다시 뒤에 넣는다.
+	ready []*keyQueue
+	// ready 에 대기열이 들어오거나 pool 이 멈추면 깨운다.
+	readyCond *sync.Cond
+	// 대기열에 자리가 나거나 pool 이 멈추면 깨운다.
+	spaceCond *sync.Cond
+	stopped   bool
+}
+
+type keyQueue struct {
+	key   string
+	tasks []func()
+	// ready 에 있거나 worker 가 실행 중이면 true
+	scheduled bool
+}
+
+// workers 가 0 이면 CPU 수만큼, queueSize 가 0 이면 key 마다 64 개의 작업을 기다리게 한다.
+func NewWorkerPool(workers int, queueSize int) *WorkerPool {
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	if queueSize <= 0 {
+		queueSize = defaultDispatchQueueSize
+	}
+
+	pool := &WorkerPool{
+		queueSize: queueSize,
+		queues:    make(map[string]*keyQueue),
+	}
+	pool.readyCond = sync.NewCond(&pool.lock)
+	pool.spaceCond = sync.NewCond(&pool.lock)
+
+	for i := 0; i < workers; i++ {
+		go pool.work()
+	}
+
+	return pool
+}
+
+// Dispatch 는 key 의 대기열에 task 를 넣는다. 대기열이 가득 차 있으면 기다리며, 멈춘 pool 에서는 task 를 버린다.
+func (p *WorkerPool) Dispatch(key string, task func()) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var queue *keyQueue
+	for !p.stopped {
+		queue = p.queues[key]
+		if queue == nil {
+			queue = &keyQueue{key: key}
+			p.queues[key] = queue
+		}
+
+		if len(queue.tasks) < p.queueSize {
+			break
+		}
+
+		p.spaceCond.Wait()
+	}
+
+	if p.stopped {
+		return
+	}
+
+	queue.tasks = append(queue.tasks, task)
+
+	if !queue.scheduled {
+		queue.scheduled = true
+		p.ready = append(p.ready, queue)
+		p.readyCond.Signal()
+	}
+}
+
+// Stop 은 pool 을 멈춘다. worker 는 대기열에 남은 작업을 실행한 뒤 종료한다.
+func (p *WorkerPool) Stop() {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.stopped = true
+	p.readyCond.Broadcast()
+	p.spaceCond.Broadcast()
+}
+
+func (p *WorkerPool) work() {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for {
+		for len(p.ready) == 0 && !p.stopped {
+			p.readyCond.Wait()
+		}
+
+		if len(p.ready) == 0 {
+			return
+		}
+
+		queue := p.ready[0]
+		p.ready[0] = nil
+		p.ready = p.ready[1:]
+
+		task := queue.tasks[0]
+		queue.tasks[0] = nil
+		queue.tasks = queue.tasks[1:]
+		p.spaceCond.Broadcast()
+
+		p.lock.Unlock()
+		task()
+		p.lock.Lock()
+
+		if len(queue.tasks) > 0 {
+			p.ready = append(p.ready, queue)
+			p.readyCond.Signal()
+			continue
+		}
+
+		queue.scheduled = false
+		delete(p.queues, queue.key)
+	}
+}
@@ -0,0 +1,110 @@
+package bifrost_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/DE-labtory/bifrost/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_Dispatch(t *testing.T) {
+	// given
+	pool := bifrost.NewWorkerPool(4, 0)
+	defer pool.Stop()
+
+	var lock sync.Mutex
+	var order []int
+	wg := sync.WaitGroup{}
+	wg.Add(100)
+
+	// when
+	for i := 0; i < 100; i++ {
+		i := i
+		pool.Dispatch("block", func() {
+			lock.Lock()
+			defer lock.Unlock()
+			order = append(order, i)
+			wg.Done()
+		})
+	}
+
+	// then
+	wg.Wait()
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, i, order[i])
+	}
+}
+
+func TestWorkerPool_Dispatch_whenKeyBlocked(t *testing.T) {
+	// given
+	pool := bifrost.NewWorkerPool(2, 0)
+	defer pool.Stop()
+
+	release := make(chan struct{})
+	pool.Dispatch("block", func() {
+		<-release
+	})
+	pool.Dispatch("block", func() {})
+
+	done := make(chan struct{}, 10)
+
+	// when
+	for i := 0; i < 10; i++ {
+		pool.Dispatch(fmt.Sprintf("key-%d", i), func() {
+			done <- struct{}{}
+		})
+	}
+
+	// then
+	// 어떤 key 를 쓰든 막힌 key 가 쉬는 worker 를 차지하지 않는다.
+	for i := 0; i < 10; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("tasks are blocked by another key")
+		}
+	}
+	close(release)
+}
+
+func TestGrpcConnection_Start_whenDispatchWorkers(t *testing.T) {
+	// given
+	// key 의 hash 로 worker 를 정했다면 "vote" 와 "tx" 는 worker 4 개 중 같은 worker 에 배정된다.
+	localConn, remoteConn := newTestConnPair(t, bifrost.ConnOpts{DispatchWorkers: 4})
+
+	voteReceived := make(chan struct{})
+	blockDone := make(chan struct{})
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		switch message.Envelope.Protocol {
+		case "tx":
+			// vote 가 먼저 처리되지 않으면 tx handler 는 끝나지 않는다.
+			select {
+			case <-voteReceived:
+			case <-time.After(3 * time.Second):
+			}
+			close(blockDone)
+		case "vote":
+			close(voteReceived)
+		}
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// when
+	assert.NoError(t, localConn.SendSync([]byte("tx"), "tx"))
+	assert.NoError(t, localConn.SendSync([]byte("vote"), "vote"))
+
+	// then
+	select {
+	case <-voteReceived:
+	case <-time.After(time.Second):
+		t.Fatal("vote handler is blocked by tx handler")
+	}
+	<-blockDone
+}
@@ -0,0 +1,77 @@
+package bifrost
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/DE-labtory/bifrost/pb"
+)
+
+var ErrNoOriginKey = errors.New("envelope has no origin key")
+var ErrInvalidOriginSignature = errors.New("invalid origin signature")
+
+// 서명 대상 인코딩의 버전. 인코딩 방식이 바뀌면 올려서 이전 서명과 구분한다.
+const signingVersion = "bifrost-envelope-v1"
+
+// SigningBytes 는 envelope 에서 서명 대상이 되는 field 들을 결정적인(deterministic) byte 열로 인코딩한다.
+// protobuf 직렬화는 결정적이지 않으므로 서명에 사용하지 않는다.
+//
+// 각 field 는 field 번호와 길이를 앞에 붙여 field 번호 순서로 이어붙인다.
+// 따라서 field 사이의 경계를 옮기거나 field 를 바꿔치기해서 같은 byte 열을 만들 수 없다.
+// Envelope 에 header field 를 추가하면 여기에도 추가해야 서명으로 보호된다.
+// 단, encrypted 처럼 서명 후에 적용되는 전송용 field 는 포함하지 않는다.
+func SigningBytes(envelope *pb.Envelope) []byte {
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(signingVersion)
+
+	writeField(buf, 1, envelope.Payload)
+	writeField(buf, 3, envelope.Pubkey)
+	writeField(buf, 4, []byte(envelope.Protocol))
+	writeUint64Field(buf, 5, uint64(envelope.Type))
+	writeUint64Field(buf, 6, envelope.Seq)
+	writeUint64Field(buf, 7, uint64(envelope.Timestamp))
+	writeUint64Field(buf, 9, envelope.RequestId)
+	writeUint64Field(buf, 11, envelope.AckId)
+	writeUint64Field(buf, 12, uint64(envelope.Lane))
+
+	return buf.Bytes()
+}
+
+func writeField(buf *bytes.Buffer, fieldNum uint32, value []byte) {
+	header := make([]byte, 12)
+	binary.BigEndian.PutUint32(header[:4], fieldNum)
+	binary.BigEndian.PutUint64(header[4:], uint64(len(value)))
+
+	buf.Write(header)
+	buf.Write(value)
+}
+
+func writeUint64Field(buf *bytes.Buffer, fieldNum uint32, value uint64) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, value)
+
+	writeField(buf, fieldNum, b)
+}
+
+// VerifyOrigin 은 envelope 에 담긴 작성자의 public key 로 서명을 검증하고 작성자의 key 를 반환한다.
+// 연결된 peer 가 아닌 다른 peer 가 작성해 전달(relay)된 envelope 도 작성자를 확인할 수 있다.
+func VerifyOrigin(envelope *pb.Envelope, crypto Crypto) (Key, error) {
+
+	if len(envelope.Pubkey) == 0 {
+		return nil, ErrNoOriginKey
+	}
+
+	originKey, err := crypto.RecoverKeyFromByte(envelope.Pubkey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	flag, err := crypto.Verify(originKey, envelope.Signature, SigningBytes(envelope))
+	if err != nil || !flag {
+		return nil, ErrInvalidOriginSignature
+	}
+
+	return originKey, nil
+}
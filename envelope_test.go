@@ -0,0 +1,77 @@
+package bifrost_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/DE-labtory/bifrost/mocks"
+	"github.com/DE-labtory/bifrost/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigningBytes(t *testing.T) {
+	// given
+	envelope := &pb.Envelope{
+		Payload:   []byte("payload"),
+		Pubkey:    []byte("key"),
+		Protocol:  "test",
+		Type:      pb.Envelope_NORMAL,
+		Signature: []byte("signature"),
+	}
+
+	// when
+	b1 := bifrost.SigningBytes(envelope)
+	envelope.Signature = []byte("other signature")
+	b2 := bifrost.SigningBytes(envelope)
+
+	// then
+	assert.Equal(t, b1, b2)
+}
+
+func TestSigningBytes_whenFieldBoundaryMoved(t *testing.T) {
+	// given
+	envelope := &pb.Envelope{Payload: []byte("ab"), Protocol: "c"}
+	moved := &pb.Envelope{Payload: []byte("a"), Protocol: "bc"}
+
+	// when
+	b1 := bifrost.SigningBytes(envelope)
+	b2 := bifrost.SigningBytes(moved)
+
+	// then
+	assert.NotEqual(t, b1, b2)
+}
+
+func TestVerifyOrigin(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	crypto := mocks.NewMockCryptoWithKey(keyOpts.PriKey)
+
+	pubKeyBytes, err := keyOpts.PubKey.ToByte()
+	assert.NoError(t, err)
+
+	envelope := &pb.Envelope{Payload: []byte("payload"), Pubkey: pubKeyBytes, Protocol: "test", Seq: 1}
+	envelope.Signature, err = crypto.Sign(bifrost.SigningBytes(envelope))
+	assert.NoError(t, err)
+
+	// when
+	origin, err := bifrost.VerifyOrigin(envelope, crypto)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, keyOpts.PubKey.ID(), origin.ID())
+
+	// when
+	envelope.Payload = []byte("tampered")
+	_, err = bifrost.VerifyOrigin(envelope, crypto)
+
+	// then
+	assert.Equal(t, bifrost.ErrInvalidOriginSignature, err)
+}
+
+func TestVerifyOrigin_whenNoOriginKey(t *testing.T) {
+	// when
+	_, err := bifrost.VerifyOrigin(&pb.Envelope{Payload: []byte("payload")}, mocks.NewMockCrypto())
+
+	// then
+	assert.Equal(t, bifrost.ErrNoOriginKey, err)
+}
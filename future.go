@@ -0,0 +1,54 @@
+package bifrost
+
+import (
+	"context"
+	"sync"
+)
+
+// SendFuture 는 SendAsync 로 보낸 메세지의 전송 결과이다.
+// Done 이 닫히면 stream 에 쓰기를 마쳤거나 실패한 것이며, 결과는 Err 로 확인한다.
+type SendFuture struct {
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+func newSendFuture() *SendFuture {
+	return &SendFuture{
+		done: make(chan struct{}),
+	}
+}
+
+func (f *SendFuture) resolve(err error) {
+	f.once.Do(func() {
+		f.err = err
+		close(f.done)
+	})
+}
+
+// Done 은 전송이 끝나면 닫히는 channel 을 반환한다. select 에서 사용할 수 있다.
+func (f *SendFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Err 는 전송 결과를 반환한다. Done 이 닫히기 전에는 nil 을 반환한다.
+func (f *SendFuture) Err() error {
+
+	select {
+	case <-f.done:
+		return f.err
+	default:
+		return nil
+	}
+}
+
+// Wait 는 전송이 끝날 때까지 기다린다. ctx 가 먼저 끝나면 ctx.Err() 를 반환한다.
+func (f *SendFuture) Wait(ctx context.Context) error {
+
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
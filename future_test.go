@@ -0,0 +1,68 @@
+package bifrost_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/DE-labtory/bifrost/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrpcConnection_SendSync(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPair(t, bifrost.ConnOpts{})
+
+	received := make(chan []byte, 1)
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		received <- message.Data
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// when
+	err := localConn.SendSync([]byte("hello"), "test")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), <-received)
+}
+
+func TestGrpcConnection_SendAsync(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPair(t, bifrost.ConnOpts{})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// when
+	future := localConn.SendAsync([]byte("hello"), "test")
+
+	// then
+	select {
+	case <-future.Done():
+		assert.NoError(t, future.Err())
+	case <-time.After(time.Second):
+		t.Fatal("send not finished")
+	}
+}
+
+func TestGrpcConnection_SendAsync_whenClosedBeforeWrite(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	conn, _ := newTestConn(t, keyOpts, bifrost.ConnOpts{}, nil)
+
+	// writeStream 이 동작하지 않으므로 대기열에 남는다.
+	future := conn.SendAsync([]byte("hello"), "test")
+	assert.Nil(t, future.Err())
+
+	// when
+	conn.Close()
+
+	// then
+	assert.Equal(t, bifrost.ErrConnClosed, future.Wait(context.Background()))
+}
@@ -1,5 +1,7 @@
 module github.com/DE-labtory/bifrost
 
+go 1.20
+
 require (
 	github.com/DE-labtory/iLogger v0.0.0-20190307073742-7009ee34b4b3
 	github.com/btcsuite/btcutil v0.0.0-20190207003914-4c204d697803
@@ -9,3 +11,14 @@ require (
 	golang.org/x/net v0.0.0-20190301231341-16b79f2e4e95
 	google.golang.org/grpc v1.19.0
 )
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/sirupsen/logrus v1.3.0 // indirect
+	golang.org/x/crypto v0.0.0-20180904163835-0709b304e793 // indirect
+	golang.org/x/sys v0.0.0-20180905080454-ebe1bf3edb33 // indirect
+	golang.org/x/text v0.3.0 // indirect
+	google.golang.org/genproto v0.0.0-20180831171423-11092d34479b // indirect
+)
@@ -0,0 +1,114 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/DE-labtory/bifrost/pb"
+	"github.com/golang/protobuf/proto"
+)
+
+// CloseCode 는 GOAWAY 로 알리는 연결 종료 사유의 종류이다.
+type CloseCode uint32
+
+const (
+	CloseNormal CloseCode = iota
+	// node 가 종료되는 중
+	CloseShuttingDown
+	// 상대방이 약속을 어긴 메세지를 보냄
+	CloseProtocolError
+	// 연결 수 제한, 차단 등 정책에 따라 연결을 끊음
+	ClosePolicyViolation
+)
+
+// CloseReason 은 CloseGracefully 에서 상대방에게 알리는 연결 종료 사유이다.
+type CloseReason struct {
+	Code    CloseCode
+	Message string
+}
+
+// GoAwayError 는 상대방이 GOAWAY 로 알린 연결 종료 사유이다.
+// 상대방이 CloseGracefully 로 연결을 끊으면 Start 가 반환하고 Handler.ServeError 로 전달된다.
+type GoAwayError struct {
+	Reason CloseReason
+}
+
+func (e *GoAwayError) Error() string {
+	return fmt.Sprintf("peer closed connection [%d]: %s", e.Reason.Code, e.Reason.Message)
+}
+
+// CloseGracefully 는 더 이상 메세지를 받지 않고, 대기열에 남은 메세지를 모두 보낸 뒤
+// 종료 사유를 담은 GOAWAY 를 보내고 연결을 끊는다.
+// 전송 중인 메세지가 버려지지 않도록 GOAWAY 를 받은 상대방이 먼저 연결을 끊을 때까지 기다린다.
+// ctx 가 먼저 끝나면 바로 연결을 끊고 ctx.Err() 를 반환하며, 보내지 못한 메세지의 errCallBack 은 ErrConnClosed 로 호출된다.
+func (conn *GrpcConnection) CloseGracefully(ctx context.Context, reason CloseReason) error {
+
+	defer conn.Close()
+
+	payload, err := proto.Marshal(&pb.GoAway{Code: uint32(reason.Code), Message: reason.Message})
+	if err != nil {
+		return err
+	}
+
+	if !atomic.CompareAndSwapInt32(&conn.draining, 0, 1) {
+		return ErrConnClosed
+	}
+
+	conn.setState(ConnDraining)
+
+	result := make(chan error, 1)
+
+	if err := conn.drain(ctx, &pb.Envelope{Type: pb.Envelope_GOAWAY, Payload: payload}, result); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-result:
+		if err != nil {
+			return err
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-conn.closed:
+		return ErrConnClosed
+	}
+
+	select {
+	case <-conn.readDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-conn.closed:
+		return ErrConnClosed
+	}
+}
+
+// drain 은 대기열이 비면 goAway 를 마지막 메세지로 넣는다.
+// write lock 을 잡으므로 draining 으로 바뀌기 전에 보내기 시작한 메세지도 모두 대기열에 들어간 뒤이다.
+func (conn *GrpcConnection) drain(ctx context.Context, goAway *pb.Envelope, result chan error) error {
+
+	conn.Lock()
+	defer conn.Unlock()
+
+	if err := conn.waitIdle(ctx); err != nil {
+		return err
+	}
+
+	return conn.enqueueControl(ctx, goAway, func(interface{}) {
+		result <- nil
+	}, func(err error) {
+		result <- err
+	})
+}
+
+// receiveGoAway 는 상대방이 보낸 종료 사유를 GoAwayError 로 만든다.
+func receiveGoAway(envelope *pb.Envelope) error {
+
+	goAway := &pb.GoAway{}
+	if err := proto.Unmarshal(envelope.Payload, goAway); err != nil {
+		return err
+	}
+
+	return &GoAwayError{Reason: CloseReason{Code: CloseCode(goAway.Code), Message: goAway.Message}}
+}
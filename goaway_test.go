@@ -0,0 +1,67 @@
+package bifrost_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/DE-labtory/bifrost/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrpcConnection_CloseGracefully(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPair(t, bifrost.ConnOpts{})
+
+	var received int32
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		atomic.AddInt32(&received, 1)
+	}})
+
+	// writeStream 이 시작되기 전에 대기열에 넣는다.
+	for i := 0; i < 50; i++ {
+		localConn.Send([]byte("hello"), "test", nil, nil)
+	}
+
+	localDone := make(chan error, 1)
+	remoteDone := make(chan error, 1)
+	go func() {
+		localDone <- localConn.Start()
+	}()
+	go func() {
+		remoteDone <- remoteConn.Start()
+	}()
+
+	reason := bifrost.CloseReason{Code: bifrost.CloseShuttingDown, Message: "node shutting down"}
+
+	// when
+	err := localConn.CloseGracefully(context.Background(), reason)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, &bifrost.GoAwayError{Reason: reason}, <-remoteDone)
+	assert.NoError(t, <-localDone)
+	assert.Equal(t, int32(50), atomic.LoadInt32(&received))
+	assert.Equal(t, bifrost.ErrConnClosed, localConn.SendSync([]byte("hello"), "test"))
+}
+
+func TestGrpcConnection_CloseGracefully_whenTimeout(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	conn, _ := newTestConn(t, keyOpts, bifrost.ConnOpts{}, nil)
+
+	// writeStream 이 동작하지 않으므로 대기열이 비지 않는다.
+	future := conn.SendAsync([]byte("hello"), "test")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// when
+	err := conn.CloseGracefully(ctx, bifrost.CloseReason{Code: bifrost.CloseNormal})
+
+	// then
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, bifrost.ErrConnClosed, future.Wait(context.Background()))
+}
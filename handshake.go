@@ -0,0 +1,347 @@
+package bifrost
+
+import (
+	"context"
+	"crypto/ecdh"
+	"errors"
+	"time"
+
+	"github.com/DE-labtory/bifrost/pb"
+	"github.com/DE-labtory/iLogger"
+)
+
+// handshake 단계의 제한 시간 안에 상대방의 메세지를 받지 못한 경우 발생하는 에러
+var ErrHandshakeTimeout = errors.New("handshake timeout")
+
+// handshake 단계에서 기대하지 않은 타입의 메세지를 받은 경우 발생하는 에러
+var ErrUnexpectedMessage = errors.New("unexpected handshake message")
+
+const (
+	defaultChallengeTimeout = 10 * time.Second
+	defaultPeerInfoTimeout  = 3 * time.Second
+)
+
+// HandshakeOpts 는 handshake 단계별 제한 시간. 값을 지정하지 않은(zero value) field 는 기본값을 사용한다.
+type HandshakeOpts struct {
+	// client 가 server 의 challenge(request peer info) 를 기다리는 시간. 기본값은 10초.
+	ChallengeTimeout time.Duration
+	// 상대방의 peer info 를 기다리는 시간. 기본값은 3초.
+	PeerInfoTimeout time.Duration
+}
+
+type HandshakeState int
+
+const (
+	HandshakeIdle HandshakeState = iota
+	// server 가 challenge 를 보냈거나 client 가 challenge 를 받은 상태
+	HandshakeChallengeExchanged
+	// client 가 challenge 에 서명한 peer info 를 보낸 상태
+	HandshakePeerInfoSent
+	// 상대방의 peer info 와 서명을 검증한 상태
+	HandshakePeerInfoVerified
+	HandshakeEstablished
+	HandshakeFailed
+)
+
+// HandshakeResult 는 handshake 에서 확인한 상대방의 정보와 합의한 session 이다.
+type HandshakeResult struct {
+	PeerKey  Key
+	PeerInfo *PeerInfo
+	Session  *Session
+}
+
+// PeerVerifier 는 서명을 검증한 상대방의 peer info 를 확인한다. error 를 반환하면 handshake 는 실패한다.
+// server 는 error 를 거절 사유로 client 에게 알린다.
+type PeerVerifier func(peerKey Key, peerInfo *PeerInfo) error
+
+// Handshaker 는 client 와 server 가 공유하는 handshake 과정이다.
+//
+//  1. server 는 nonce 를 담은 REQUEST_PEERINFO 를 보낸다.
+//  2. client 는 server nonce 에 서명한 자신의 peer info (RESPONSE_PEERINFO) 를 보낸다.
+//  3. server 는 서명을 검증하고 version 과 기능을 합의한 뒤, client nonce 에 서명한 자신의 peer info 를 보낸다.
+//  4. client 는 server 의 서명을 검증하고 같은 방식으로 version 과 기능을 합의한다.
+//
+// 실패하면 stream 을 닫는다. 닫힌 stream 의 Recv 가 반환되므로 메세지를 기다리던 goroutine 도 남지 않는다.
+type Handshaker struct {
+	stream     StreamWrapper
+	crypto     Crypto
+	local      *PeerInfo
+	connOpts   ConnOpts
+	opts       HandshakeOpts
+	isClient   bool
+	verifyPeer PeerVerifier
+	state      HandshakeState
+}
+
+type recvResult struct {
+	envelope *pb.Envelope
+	err      error
+}
+
+// local 은 상대방에게 보낼 자신의 peer info 이며, nonce 와 ephemeral key 는 handshake 과정에서 채운다.
+func NewClientHandshaker(stream StreamWrapper, crypto Crypto, local *PeerInfo, connOpts ConnOpts, opts HandshakeOpts) *Handshaker {
+	return newHandshaker(stream, crypto, local, connOpts, opts, true)
+}
+
+func NewServerHandshaker(stream StreamWrapper, crypto Crypto, local *PeerInfo, connOpts ConnOpts, opts HandshakeOpts) *Handshaker {
+	return newHandshaker(stream, crypto, local, connOpts, opts, false)
+}
+
+func newHandshaker(stream StreamWrapper, crypto Crypto, local *PeerInfo, connOpts ConnOpts, opts HandshakeOpts, isClient bool) *Handshaker {
+
+	if opts.ChallengeTimeout == 0 {
+		opts.ChallengeTimeout = defaultChallengeTimeout
+	}
+
+	if opts.PeerInfoTimeout == 0 {
+		opts.PeerInfoTimeout = defaultPeerInfoTimeout
+	}
+
+	local.Features = AdvertisedFeatures(connOpts)
+	local.Protocols = connOpts.Protocols
+
+	return &Handshaker{
+		stream:   stream,
+		crypto:   crypto,
+		local:    local,
+		connOpts: connOpts,
+		opts:     opts,
+		isClient: isClient,
+		state:    HandshakeIdle,
+	}
+}
+
+// OnPeerInfo 는 상대방의 서명을 검증한 뒤 호출할 verifier 를 지정한다.
+func (h *Handshaker) OnPeerInfo(verifier PeerVerifier) {
+	h.verifyPeer = verifier
+}
+
+func (h *Handshaker) State() HandshakeState {
+	return h.state
+}
+
+// Run 은 handshake 를 진행한다. ctx 가 취소되거나 단계별 제한 시간이 지나면 실패한다.
+func (h *Handshaker) Run(ctx context.Context) (*HandshakeResult, error) {
+
+	var result *HandshakeResult
+	var err error
+
+	if h.isClient {
+		result, err = h.runClient(ctx)
+	} else {
+		result, err = h.runServer(ctx)
+	}
+
+	if err != nil {
+		h.state = HandshakeFailed
+		h.stream.Close()
+		return nil, err
+	}
+
+	h.state = HandshakeEstablished
+
+	return result, nil
+}
+
+func (h *Handshaker) runClient(ctx context.Context) (*HandshakeResult, error) {
+
+	challenge, err := h.recv(ctx, h.opts.ChallengeTimeout, pb.Envelope_REQUEST_PEERINFO)
+	if err != nil {
+		return nil, err
+	}
+
+	serverNonce := challenge.Payload
+	if len(serverNonce) != NonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	h.state = HandshakeChallengeExchanged
+
+	if h.local.Nonce, err = NewNonce(); err != nil {
+		return nil, err
+	}
+
+	var ephemeralKey *ecdh.PrivateKey
+	if h.connOpts.EncryptionEnabled {
+		if ephemeralKey, err = NewEphemeralKey(); err != nil {
+			return nil, err
+		}
+		h.local.EphemeralKey = ephemeralKey.PublicKey().Bytes()
+	}
+
+	if err := h.sendPeerInfo(serverNonce); err != nil {
+		return nil, err
+	}
+
+	h.state = HandshakePeerInfoSent
+
+	peerKey, peerInfo, err := h.recvPeerInfo(ctx, h.local.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.verify(peerKey, peerInfo); err != nil {
+		return nil, err
+	}
+
+	session, err := h.negotiate(peerInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if ephemeralKey != nil {
+		if err := session.EstablishKeys(ephemeralKey, peerInfo.EphemeralKey, serverNonce, h.local.Nonce, true); err != nil {
+			return nil, err
+		}
+	}
+
+	return &HandshakeResult{PeerKey: peerKey, PeerInfo: peerInfo, Session: session}, nil
+}
+
+func (h *Handshaker) runServer(ctx context.Context) (*HandshakeResult, error) {
+
+	nonce, err := NewNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.stream.Send(BuildRequestPeerInfo(nonce)); err != nil {
+		return nil, err
+	}
+
+	h.state = HandshakeChallengeExchanged
+
+	peerKey, peerInfo, err := h.recvPeerInfo(ctx, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.verify(peerKey, peerInfo); err != nil {
+		return nil, h.reject(err)
+	}
+
+	session, err := h.negotiate(peerInfo)
+	if err != nil {
+		return nil, h.reject(err)
+	}
+
+	if session.Features.Has(FeatureEncryption) {
+		ephemeralKey, err := NewEphemeralKey()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := session.EstablishKeys(ephemeralKey, peerInfo.EphemeralKey, nonce, peerInfo.Nonce, false); err != nil {
+			return nil, err
+		}
+
+		h.local.EphemeralKey = ephemeralKey.PublicKey().Bytes()
+	}
+
+	if err := h.sendPeerInfo(peerInfo.Nonce); err != nil {
+		return nil, err
+	}
+
+	return &HandshakeResult{PeerKey: peerKey, PeerInfo: peerInfo, Session: session}, nil
+}
+
+// sendPeerInfo 는 상대방의 nonce 에 서명한 자신의 peer info 를 보낸다.
+func (h *Handshaker) sendPeerInfo(peerNonce []byte) error {
+
+	envelope, err := BuildResponsePeerInfo(h.local, peerNonce, h.crypto.Signer)
+	if err != nil {
+		return err
+	}
+
+	return h.stream.Send(envelope)
+}
+
+// recvPeerInfo 는 상대방의 peer info 를 받고, 자신이 보낸 nonce 에 대한 서명을 검증한다.
+func (h *Handshaker) recvPeerInfo(ctx context.Context, nonce []byte) (Key, *PeerInfo, error) {
+
+	envelope, err := h.recv(ctx, h.opts.PeerInfoTimeout, pb.Envelope_RESPONSE_PEERINFO)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	peerKey, peerInfo, err := VerifyResponsePeerInfo(envelope, nonce, h.crypto)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	h.state = HandshakePeerInfoVerified
+
+	return peerKey, peerInfo, nil
+}
+
+func (h *Handshaker) verify(peerKey Key, peerInfo *PeerInfo) error {
+
+	if h.verifyPeer == nil {
+		return nil
+	}
+
+	return h.verifyPeer(peerKey, peerInfo)
+}
+
+// negotiate 는 상대방과 version 과 기능을 합의한다. 암호화를 사용하는데 상대방이 지원하지 않으면 실패한다.
+func (h *Handshaker) negotiate(peerInfo *PeerInfo) (*Session, error) {
+
+	session, err := Negotiate(h.local, peerInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.connOpts.EncryptionEnabled && !session.Features.Has(FeatureEncryption) {
+		return nil, ErrEncryptionNotSupported
+	}
+
+	return session, nil
+}
+
+// recv 는 제한 시간 안에 expected 타입의 메세지를 기다린다. 상대방이 거절한 경우 PeerRejectedError 를 반환한다.
+func (h *Handshaker) recv(ctx context.Context, timeout time.Duration, expected pb.Envelope_Type) (*pb.Envelope, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// stream 의 Recv 는 context 를 받지 않으므로 goroutine 에서 기다린다.
+	// channel 에 buffer 가 있으므로 Run 이 먼저 반환해도 Recv 가 끝나면 goroutine 은 종료된다.
+	result := make(chan recvResult, 1)
+
+	go func() {
+		envelope, err := h.stream.Recv()
+		result <- recvResult{envelope: envelope, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, ErrHandshakeTimeout
+		}
+		return nil, ctx.Err()
+
+	case r := <-result:
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		switch r.envelope.GetType() {
+		case expected:
+			return r.envelope, nil
+		case pb.Envelope_REJECT_PEER:
+			return nil, &PeerRejectedError{Reason: string(r.envelope.Payload)}
+		default:
+			return nil, ErrUnexpectedMessage
+		}
+	}
+}
+
+// reject 는 거절 사유를 상대방에게 알리고 PeerRejectedError 를 반환한다.
+func (h *Handshaker) reject(reason error) error {
+
+	if err := h.stream.Send(BuildRejectPeer(reason.Error())); err != nil {
+		iLogger.Infof(nil, "[Bifrost] Fail to send reject reason [%s]", err.Error())
+	}
+
+	return &PeerRejectedError{Reason: reason.Error()}
+}
@@ -0,0 +1,148 @@
+package bifrost_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/DE-labtory/bifrost/mocks"
+	"github.com/DE-labtory/bifrost/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+type handshakeOutcome struct {
+	result *bifrost.HandshakeResult
+	err    error
+}
+
+func newTestHandshakers(t *testing.T, stream bifrost.StreamWrapper, peerStream bifrost.StreamWrapper, connOpts bifrost.ConnOpts) (*bifrost.Handshaker, *bifrost.Handshaker, bifrost.KeyOpts, bifrost.KeyOpts) {
+	clientKeyOpts := mocks.NewMockKeyOpts()
+	serverKeyOpts := mocks.NewMockKeyOpts()
+
+	clientInfo, err := bifrost.NewPeerInfo("127.0.0.1:1234", clientKeyOpts.PubKey, nil)
+	assert.NoError(t, err)
+	serverInfo, err := bifrost.NewPeerInfo("127.0.0.1:1235", serverKeyOpts.PubKey, nil)
+	assert.NoError(t, err)
+
+	client := bifrost.NewClientHandshaker(stream, mocks.NewMockCryptoWithKey(clientKeyOpts.PriKey), clientInfo, connOpts, bifrost.HandshakeOpts{})
+	server := bifrost.NewServerHandshaker(peerStream, mocks.NewMockCryptoWithKey(serverKeyOpts.PriKey), serverInfo, connOpts, bifrost.HandshakeOpts{})
+
+	return client, server, clientKeyOpts, serverKeyOpts
+}
+
+func runHandshake(handshaker *bifrost.Handshaker) chan handshakeOutcome {
+	done := make(chan handshakeOutcome, 1)
+
+	go func() {
+		result, err := handshaker.Run(context.Background())
+		done <- handshakeOutcome{result: result, err: err}
+	}()
+
+	return done
+}
+
+func TestHandshaker_Run(t *testing.T) {
+	// given
+	local, remote := mocks.NewMockStreamPipe()
+	client, server, clientKeyOpts, serverKeyOpts := newTestHandshakers(t, local, remote, bifrost.ConnOpts{EncryptionEnabled: true})
+
+	// when
+	serverDone := runHandshake(server)
+	clientDone := runHandshake(client)
+
+	// then
+	clientOutcome := <-clientDone
+	serverOutcome := <-serverDone
+
+	assert.NoError(t, clientOutcome.err)
+	assert.NoError(t, serverOutcome.err)
+	assert.Equal(t, serverKeyOpts.PubKey.ID(), clientOutcome.result.PeerKey.ID())
+	assert.Equal(t, clientKeyOpts.PubKey.ID(), serverOutcome.result.PeerKey.ID())
+	assert.True(t, clientOutcome.result.Session.Encrypted())
+	assert.True(t, serverOutcome.result.Session.Encrypted())
+	assert.Equal(t, bifrost.HandshakeEstablished, client.State())
+	assert.Equal(t, bifrost.HandshakeEstablished, server.State())
+}
+
+func TestHandshaker_Run_whenPeerRejected(t *testing.T) {
+	// given
+	local, remote := mocks.NewMockStreamPipe()
+	client, server, _, _ := newTestHandshakers(t, local, remote, bifrost.ConnOpts{})
+	server.OnPeerInfo(func(peerKey bifrost.Key, peerInfo *bifrost.PeerInfo) error {
+		return errors.New("not in allowlist")
+	})
+
+	// when
+	serverDone := runHandshake(server)
+	clientDone := runHandshake(client)
+
+	// then
+	assert.Equal(t, &bifrost.PeerRejectedError{Reason: "not in allowlist"}, (<-clientDone).err)
+	assert.True(t, errors.Is((<-serverDone).err, bifrost.ErrPeerRejected))
+	assert.Equal(t, bifrost.HandshakeFailed, client.State())
+}
+
+func TestHandshaker_Run_whenTimeout(t *testing.T) {
+	// given
+	goroutines := runtime.NumGoroutine()
+
+	local, _ := mocks.NewMockStreamPipe()
+	keyOpts := mocks.NewMockKeyOpts()
+	peerInfo, err := bifrost.NewPeerInfo("127.0.0.1:1234", keyOpts.PubKey, nil)
+	assert.NoError(t, err)
+
+	opts := bifrost.HandshakeOpts{ChallengeTimeout: 100 * time.Millisecond}
+	client := bifrost.NewClientHandshaker(local, mocks.NewMockCryptoWithKey(keyOpts.PriKey), peerInfo, bifrost.ConnOpts{}, opts)
+
+	// when
+	_, err = client.Run(context.Background())
+
+	// then
+	assert.Equal(t, bifrost.ErrHandshakeTimeout, err)
+
+	// stream 이 닫히면 Recv 를 기다리던 goroutine 도 종료되어야 한다.
+	for i := 0; i < 100 && runtime.NumGoroutine() > goroutines; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, runtime.NumGoroutine() <= goroutines)
+}
+
+func TestHandshaker_Run_whenContextCanceled(t *testing.T) {
+	// given
+	local, _ := mocks.NewMockStreamPipe()
+	keyOpts := mocks.NewMockKeyOpts()
+	peerInfo, err := bifrost.NewPeerInfo("127.0.0.1:1234", keyOpts.PubKey, nil)
+	assert.NoError(t, err)
+
+	client := bifrost.NewClientHandshaker(local, mocks.NewMockCryptoWithKey(keyOpts.PriKey), peerInfo, bifrost.ConnOpts{}, bifrost.HandshakeOpts{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	_, err = client.Run(ctx)
+
+	// then
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestHandshaker_Run_whenUnexpectedMessage(t *testing.T) {
+	// given
+	local, remote := mocks.NewMockStreamPipe()
+	keyOpts := mocks.NewMockKeyOpts()
+	peerInfo, err := bifrost.NewPeerInfo("127.0.0.1:1234", keyOpts.PubKey, nil)
+	assert.NoError(t, err)
+
+	client := bifrost.NewClientHandshaker(local, mocks.NewMockCryptoWithKey(keyOpts.PriKey), peerInfo, bifrost.ConnOpts{}, bifrost.HandshakeOpts{})
+
+	go remote.Send(&pb.Envelope{Type: pb.Envelope_NORMAL})
+
+	// when
+	_, err = client.Run(context.Background())
+
+	// then
+	assert.Equal(t, bifrost.ErrUnexpectedMessage, err)
+}
@@ -0,0 +1,113 @@
+package bifrost
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/DE-labtory/bifrost/pb"
+)
+
+// 상대방이 연속으로 heartbeat 에 응답하지 않아 연결을 끊은 경우 발생하는 에러
+var ErrPeerUnresponsive = errors.New("peer unresponsive")
+
+const defaultMaxMissedHeartbeats = 3
+
+// heartbeat 는 주기적으로 PING 을 보내고 PONG 으로 RTT 를 측정한다.
+// rtt 를 제외한 field 는 Start 의 loop 에서만 사용한다.
+type heartbeat struct {
+	interval  time.Duration
+	maxMissed int
+	nextID    uint64
+	// 응답을 기다리는 PING 의 id 별로 보낸 시각을 기억해 RTT 가 interval 보다 길어도 측정한다.
+	// 연속으로 maxMissed 번 놓치면 연결을 끊으므로 최대 maxMissed 개만 남는다.
+	sentAt map[uint64]time.Time
+	missed int
+	// 평활화한 RTT (nanosecond)
+	srtt int64
+}
+
+func newHeartbeat(interval time.Duration, maxMissed int) *heartbeat {
+
+	if maxMissed <= 0 {
+		maxMissed = defaultMaxMissedHeartbeats
+	}
+
+	return &heartbeat{
+		interval:  interval,
+		maxMissed: maxMissed,
+		sentAt:    make(map[uint64]time.Time),
+	}
+}
+
+// ping 은 보낼 PING 의 id 를 발급한다. 이전 PING 의 응답을 받지 못했으면 놓친 것으로 세고,
+// 연속으로 maxMissed 번 놓치면 ErrPeerUnresponsive 를 반환한다.
+func (h *heartbeat) ping(now time.Time) (uint64, error) {
+
+	if _, ok := h.sentAt[h.nextID]; ok {
+		h.missed++
+
+		if h.missed >= h.maxMissed {
+			return 0, ErrPeerUnresponsive
+		}
+	}
+
+	h.nextID++
+	h.sentAt[h.nextID] = now
+
+	return h.nextID, nil
+}
+
+// pong 은 기다리던 PING 의 응답이면 RTT 를 반영한다. 다음 PING 을 보낸 뒤에 도착한 응답도 반영한다.
+// PONG 은 보낸 순서대로 도착하므로 응답한 PING 보다 이전의 PING 은 더 기다리지 않는다.
+func (h *heartbeat) pong(id uint64, now time.Time) bool {
+
+	sentAt, ok := h.sentAt[id]
+	if !ok {
+		return false
+	}
+
+	for outstanding := range h.sentAt {
+		if outstanding <= id {
+			delete(h.sentAt, outstanding)
+		}
+	}
+
+	h.missed = 0
+
+	// TCP 의 SRTT 처럼 새 측정값을 1/8 만 반영한다.
+	rtt := int64(now.Sub(sentAt))
+	srtt := atomic.LoadInt64(&h.srtt)
+
+	if srtt == 0 {
+		srtt = rtt
+	} else {
+		srtt += (rtt - srtt) / 8
+	}
+
+	atomic.StoreInt64(&h.srtt, srtt)
+
+	return true
+}
+
+func (h *heartbeat) rtt() time.Duration {
+	return time.Duration(atomic.LoadInt64(&h.srtt))
+}
+
+func heartbeatPayload(id uint64) []byte {
+
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint64(payload, id)
+
+	return payload
+}
+
+func heartbeatID(envelope *pb.Envelope) uint64 {
+
+	if len(envelope.Payload) != 8 {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(envelope.Payload)
+}
@@ -0,0 +1,79 @@
+package bifrost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeartbeat_pong(t *testing.T) {
+	// given
+	h := newHeartbeat(time.Second, 0)
+	now := time.Now()
+
+	first, err := h.ping(now)
+	assert.NoError(t, err)
+
+	// when
+	ok := h.pong(first, now.Add(80*time.Millisecond))
+
+	// then
+	assert.True(t, ok)
+	assert.Equal(t, 80*time.Millisecond, h.rtt())
+
+	// when
+	second, err := h.ping(now.Add(time.Second))
+	assert.NoError(t, err)
+	h.pong(second, now.Add(time.Second+160*time.Millisecond))
+
+	// then
+	assert.Equal(t, 90*time.Millisecond, h.rtt())
+}
+
+func TestHeartbeat_pong_whenLate(t *testing.T) {
+	// given
+	h := newHeartbeat(time.Second, 0)
+	now := time.Now()
+
+	first, _ := h.ping(now)
+	_, err := h.ping(now.Add(time.Second))
+	assert.NoError(t, err)
+
+	// when
+	ok := h.pong(first, now.Add(time.Second+time.Millisecond))
+
+	// then
+	// RTT 가 interval 보다 길어도 측정한다.
+	assert.True(t, ok)
+	assert.Equal(t, time.Second+time.Millisecond, h.rtt())
+
+	// 같은 PING 의 응답은 한 번만 반영한다.
+	assert.False(t, h.pong(first, now.Add(2*time.Second)))
+
+	// 늦게라도 응답했으므로 놓친 횟수는 다시 센다.
+	_, err = h.ping(now.Add(2 * time.Second))
+	assert.NoError(t, err)
+	_, err = h.ping(now.Add(3 * time.Second))
+	assert.NoError(t, err)
+	_, err = h.ping(now.Add(4 * time.Second))
+	assert.Equal(t, ErrPeerUnresponsive, err)
+}
+
+func TestHeartbeat_pong_whenNewerAnswered(t *testing.T) {
+	// given
+	h := newHeartbeat(time.Second, 0)
+	now := time.Now()
+
+	first, _ := h.ping(now)
+	second, _ := h.ping(now.Add(time.Second))
+	h.pong(second, now.Add(time.Second+time.Millisecond))
+
+	// when
+	ok := h.pong(first, now.Add(time.Second+2*time.Millisecond))
+
+	// then
+	assert.False(t, ok)
+	assert.Equal(t, time.Millisecond, h.rtt())
+	assert.Empty(t, h.sentAt)
+}
@@ -0,0 +1,52 @@
+package bifrost_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/DE-labtory/bifrost/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrpcConnection_RTT(t *testing.T) {
+	// given
+	// -race 처럼 느린 환경에서도 응답을 놓쳐 연결이 끊어지지 않도록 주기와 허용 횟수를 넉넉하게 둔다.
+	opts := bifrost.ConnOpts{HeartbeatInterval: 100 * time.Millisecond, MaxMissedHeartbeats: 50}
+	localConn, remoteConn := newTestConnPair(t, opts)
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// when
+	deadline := time.Now().Add(5 * time.Second)
+	for localConn.RTT() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// then
+	assert.True(t, localConn.RTT() > 0)
+	assert.NoError(t, localConn.SendSync([]byte("hello"), "test"))
+}
+
+func TestGrpcConnection_Start_whenPeerUnresponsive(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+
+	opts := bifrost.ConnOpts{HeartbeatInterval: 10 * time.Millisecond, MaxMissedHeartbeats: 2}
+	conn, _ := newTestConn(t, keyOpts, opts, nil)
+
+	errs := make(chan error, 1)
+	conn.Handle(mocks.MockFuncHandler{ErrorFunc: func(conn bifrost.Connection, err error) {
+		errs <- err
+	}})
+
+	// when
+	err := conn.Start()
+
+	// then
+	assert.Equal(t, bifrost.ErrPeerUnresponsive, err)
+	assert.Equal(t, bifrost.ErrPeerUnresponsive, <-errs)
+	assert.Equal(t, time.Duration(0), conn.RTT())
+}
@@ -1,6 +1,10 @@
 package mocks
 
 import (
+	"context"
+	"io"
+	"sync"
+
 	"github.com/DE-labtory/bifrost"
 	"github.com/DE-labtory/bifrost/pb"
 )
@@ -17,7 +21,7 @@ func NewMockConnection(targetIP string) (bifrost.Connection, error) {
 
 	}
 
-	conn, err := bifrost.NewConnection(targetIP, nil, keyOpts.PubKey, mockStreamWrapper, mockCrypto)
+	conn, err := bifrost.NewConnection(targetIP, nil, keyOpts.PubKey, keyOpts.PubKey, mockStreamWrapper, mockCrypto, bifrost.ConnOpts{}, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -34,7 +38,9 @@ type MockStreamWrapper struct {
 }
 
 func (msw MockStreamWrapper) Send(envelope *pb.Envelope) error {
-	msw.SendCallBack(envelope)
+	if msw.SendCallBack != nil {
+		msw.SendCallBack(envelope)
+	}
 	return nil
 }
 
@@ -43,9 +49,90 @@ func (MockStreamWrapper) Recv() (*pb.Envelope, error) {
 }
 
 func (msw MockStreamWrapper) Close() {
-	msw.CloseCallBack()
+	if msw.CloseCallBack != nil {
+		msw.CloseCallBack()
+	}
 }
 
 func (MockStreamWrapper) GetStream() bifrost.Stream {
 	panic("implement me")
 }
+
+func (MockStreamWrapper) Context() context.Context {
+	return context.Background()
+}
+
+// MockPipeStreamWrapper 는 메모리 상에서 연결된 한 쌍의 stream 중 한쪽이다.
+// 어느 한쪽을 Close 하면 양쪽의 Send, Recv 모두 io.EOF 를 반환한다.
+type MockPipeStreamWrapper struct {
+	in     chan *pb.Envelope
+	out    chan *pb.Envelope
+	closed chan struct{}
+	once   *sync.Once
+}
+
+func NewMockStreamPipe() (*MockPipeStreamWrapper, *MockPipeStreamWrapper) {
+	a := make(chan *pb.Envelope, 100)
+	b := make(chan *pb.Envelope, 100)
+	closed := make(chan struct{})
+	once := &sync.Once{}
+
+	return &MockPipeStreamWrapper{in: a, out: b, closed: closed, once: once},
+		&MockPipeStreamWrapper{in: b, out: a, closed: closed, once: once}
+}
+
+func (p *MockPipeStreamWrapper) Send(envelope *pb.Envelope) error {
+	select {
+	case <-p.closed:
+		return io.EOF
+	default:
+	}
+
+	select {
+	case p.out <- envelope:
+		return nil
+	case <-p.closed:
+		return io.EOF
+	}
+}
+
+func (p *MockPipeStreamWrapper) Recv() (*pb.Envelope, error) {
+	select {
+	case envelope := <-p.in:
+		return envelope, nil
+	case <-p.closed:
+		return nil, io.EOF
+	}
+}
+
+func (p *MockPipeStreamWrapper) Close() {
+	p.once.Do(func() {
+		close(p.closed)
+	})
+}
+
+func (p *MockPipeStreamWrapper) GetStream() bifrost.Stream {
+	return p
+}
+
+func (p *MockPipeStreamWrapper) Context() context.Context {
+	return context.Background()
+}
+
+// MockFuncHandler 는 지정한 함수로 요청과 에러를 처리하는 handler 이다.
+type MockFuncHandler struct {
+	RequestFunc func(message bifrost.Message)
+	ErrorFunc   func(conn bifrost.Connection, err error)
+}
+
+func (h MockFuncHandler) ServeRequest(message bifrost.Message) {
+	if h.RequestFunc != nil {
+		h.RequestFunc(message)
+	}
+}
+
+func (h MockFuncHandler) ServeError(conn bifrost.Connection, err error) {
+	if h.ErrorFunc != nil {
+		h.ErrorFunc(conn, err)
+	}
+}
@@ -63,15 +63,29 @@ func NewMockCrypto() bifrost.Crypto {
 	}
 }
 
+// key 를 파일로 저장하지 않고 사용하는 경우 PriKey 에 private key 를 지정한다.
+func NewMockCryptoWithKey(priKey bifrost.Key) bifrost.Crypto {
+	crypto := NewMockCrypto()
+	crypto.Signer.(*MockECDSASigner).PriKey = priKey
+
+	return crypto
+}
+
 type MockECDSASigner struct {
 	KeyID      string
 	KeyDirPath string
+	PriKey     bifrost.Key
 }
 
 func (signer *MockECDSASigner) Sign(message []byte) ([]byte, error) {
-	priKey, err := mockLoadKey(signer.KeyID, signer.KeyDirPath)
-	if err != nil {
-		return nil, err
+	priKey := signer.PriKey
+
+	if priKey == nil {
+		key, err := mockLoadKey(signer.KeyID, signer.KeyDirPath)
+		if err != nil {
+			return nil, err
+		}
+		priKey = key
 	}
 
 	// get hash from message
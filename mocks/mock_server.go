@@ -17,6 +17,7 @@ import (
 	"github.com/DE-labtory/iLogger"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -24,13 +25,18 @@ type MockStreamServer struct {
 	countRecv int32
 	countSend int32
 	peerInfo  bifrost.PeerInfo
+	signer    bifrost.Signer
+	nonce     []byte
+	Sent      []*pb.Envelope
 }
 
-func NewMockStreamServer(peerInfo bifrost.PeerInfo) *MockStreamServer {
+// signer 는 server 가 보낸 nonce 에 서명할 client 의 signer 이다.
+func NewMockStreamServer(peerInfo bifrost.PeerInfo, signer bifrost.Signer) *MockStreamServer {
 	return &MockStreamServer{
 		countRecv: 0,
 		countSend: 0,
 		peerInfo:  peerInfo,
+		signer:    signer,
 	}
 }
 
@@ -38,9 +44,11 @@ func (s *MockStreamServer) Send(envelope *pb.Envelope) error {
 	iLogger.Info(nil, "[Bifrost] Mock send func called")
 
 	s.countSend = s.countSend + 1
+	s.Sent = append(s.Sent, envelope)
 
 	if s.countSend == 1 {
 		if envelope.Type == pb.Envelope_REQUEST_PEERINFO {
+			s.nonce = envelope.Payload
 			return nil
 		}
 		return errors.New("invalid protocol")
@@ -49,6 +57,10 @@ func (s *MockStreamServer) Send(envelope *pb.Envelope) error {
 	mockServer := NewMockServer()
 
 	if s.countSend == 2 {
+		if envelope.Type == pb.Envelope_REJECT_PEER {
+			return nil
+		}
+
 		valid, _, _ := mockServer.ValidateResponsePeerInfo(envelope)
 
 		if valid {
@@ -67,9 +79,15 @@ func (s *MockStreamServer) Recv() (*pb.Envelope, error) {
 	if s.countRecv == 1 {
 		payload, _ := json.Marshal(s.peerInfo)
 
+		sig, err := s.signer.Sign(append(append([]byte{}, s.nonce...), payload...))
+		if err != nil {
+			return nil, err
+		}
+
 		envelope := &pb.Envelope{}
 		envelope.Type = pb.Envelope_RESPONSE_PEERINFO
 		envelope.Payload = payload
+		envelope.Signature = sig
 		return envelope, nil
 	}
 
@@ -99,11 +117,11 @@ func (c *MockvalueCtx) String() string {
 
 func (c *MockvalueCtx) Value(key interface{}) interface{} {
 
-	return "127.0.0.1:7777"
+	return &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 7777}}
 }
 
 func (MockStreamServer) Context() context.Context {
-	return &MockvalueCtx{}
+	return &MockvalueCtx{Context: context.Background()}
 }
 
 func (MockStreamServer) SendMsg(m interface{}) error {
@@ -201,7 +219,7 @@ func NewMockKeyOpts() bifrost.KeyOpts {
 
 func NewMockServer() *server.Server {
 	keyOpt := NewMockKeyOpts()
-	mockCrypto := NewMockCrypto()
+	mockCrypto := NewMockCryptoWithKey(keyOpt.PriKey)
 	mockCrypto.Signer.(*MockECDSASigner).KeyID = keyOpt.PubKey.ID()
 
 	s := server.New(keyOpt, mockCrypto, nil)
@@ -2,6 +2,7 @@ package mux
 
 import (
 	"errors"
+	"sort"
 	"sync"
 
 	"github.com/DE-labtory/bifrost"
@@ -17,6 +18,7 @@ type DefaultMux struct {
 	sync.RWMutex
 	registerHandled map[Protocol]*Handle
 	errorFunc       ErrorFunc
+	reputation      *bifrost.Reputation
 }
 
 type Handle struct {
@@ -44,6 +46,23 @@ func (mux *DefaultMux) Handle(protocol Protocol, handler HandlerFunc) error {
 	return nil
 }
 
+// Protocols 는 handler 를 등록한 protocol 목록을 반환한다. handshake 에서 상대방에게 알릴 때 사용한다.
+func (mux *DefaultMux) Protocols() []string {
+
+	mux.RLock()
+	defer mux.RUnlock()
+
+	protocols := make([]string, 0, len(mux.registerHandled))
+
+	for protocol := range mux.registerHandled {
+		protocols = append(protocols, string(protocol))
+	}
+
+	sort.Strings(protocols)
+
+	return protocols
+}
+
 func (mux *DefaultMux) match(protocol Protocol) HandlerFunc {
 
 	mux.Lock()
@@ -66,7 +85,27 @@ func (mux *DefaultMux) ServeRequest(msg bifrost.Message) {
 
 	if handleFunc != nil {
 		handleFunc(msg)
+		return
 	}
+
+	// 처리하지 않는 protocol 의 메세지는 ACK 를 요청한 쪽이 전달되지 않았음을 알 수 있도록 거절한다.
+	msg.Reject(bifrost.AckUnhandled, "no handler for protocol")
+
+	// 처리하지 않는 protocol 의 메세지를 보낸 peer 는 평판을 깎는다.
+	mux.Lock()
+	reputation := mux.reputation
+	mux.Unlock()
+
+	reputation.ReportConnection(msg.Conn, bifrost.EventProtocolViolation)
+}
+
+// SetReputation 은 처리하지 않는 protocol 의 메세지를 보낸 peer 를 기록할 평판을 지정한다.
+func (mux *DefaultMux) SetReputation(reputation *bifrost.Reputation) {
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	mux.reputation = reputation
 }
 
 func (mux *DefaultMux) ServeError(conn bifrost.Connection, err error) {
@@ -45,3 +45,63 @@ func TestMux_ServeError(t *testing.T) {
 	// when
 	testMux.ServeError(conn, errors.New("testError"))
 }
+
+func TestMux_Protocols(t *testing.T) {
+	// given
+	testMux := mux.New()
+	testMux.Handle(mux.Protocol("chat"), func(message bifrost.Message) {})
+	testMux.Handle(mux.Protocol("block"), func(message bifrost.Message) {})
+
+	// when
+	protocols := testMux.Protocols()
+
+	// then
+	assert.Equal(t, []string{"block", "chat"}, protocols)
+}
+
+func TestMux_ServeRequest_whenUnknownProtocol(t *testing.T) {
+	// given
+	testMux := mux.New()
+	reputation := bifrost.NewReputation(bifrost.ReputationOpts{})
+	testMux.SetReputation(reputation)
+
+	conn, err := mocks.NewMockConnection("127.0.0.1:1234")
+	assert.NoError(t, err)
+
+	message := bifrost.Message{Conn: conn, Envelope: &pb.Envelope{Protocol: "unknown"}}
+
+	// when
+	testMux.ServeRequest(message)
+
+	// then
+	assert.Equal(t, -10, reputation.Score(conn.GetID()))
+}
+
+func TestMux_ServeRequest_whenUnknownProtocolAndAck(t *testing.T) {
+	// given
+	localKeyOpts := mocks.NewMockKeyOpts()
+	remoteKeyOpts := mocks.NewMockKeyOpts()
+	local, remote := mocks.NewMockStreamPipe()
+
+	opts := bifrost.ConnOpts{Ack: bifrost.AckOpts{Enabled: true}}
+	session := &bifrost.Session{Features: bifrost.FeatureSet{bifrost.FeatureAck}}
+
+	localConn, err := bifrost.NewConnection("127.0.0.1:1234", nil, localKeyOpts.PubKey, remoteKeyOpts.PubKey, local, mocks.NewMockCryptoWithKey(localKeyOpts.PriKey), opts, session)
+	assert.NoError(t, err)
+	remoteConn, err := bifrost.NewConnection("127.0.0.1:1235", nil, remoteKeyOpts.PubKey, localKeyOpts.PubKey, remote, mocks.NewMockCryptoWithKey(remoteKeyOpts.PriKey), opts, session)
+	assert.NoError(t, err)
+
+	testMux := mux.New()
+	testMux.Handle(mux.Protocol("chat"), func(message bifrost.Message) {})
+	remoteConn.Handle(testMux)
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// when
+	err = localConn.SendSync([]byte("hello"), "unknown")
+
+	// then
+	assert.Equal(t, &bifrost.DeliveryError{Code: bifrost.AckUnhandled, Reason: "no handler for protocol"}, err)
+}
@@ -0,0 +1,90 @@
+package bifrost
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// 현재 wire protocol version
+	ProtocolVersion uint32 = 1
+	// 연결을 허용하는 가장 낮은 wire protocol version
+	MinProtocolVersion uint32 = 1
+)
+
+var ErrIncompatibleVersion = errors.New("incompatible protocol version")
+
+// Feature 는 handshake 에서 합의하는 선택 기능이다.
+type Feature string
+
+const (
+	FeatureEncryption         Feature = "encryption"
+	FeatureCompressionDeflate Feature = "compression/deflate"
+	FeatureCompressionGzip    Feature = "compression/gzip"
+	FeatureAck                Feature = "ack"
+)
+
+// FeatureSet 은 기능 목록이다.
+type FeatureSet []Feature
+
+func (fs FeatureSet) Has(feature Feature) bool {
+	for _, f := range fs {
+		if f == feature {
+			return true
+		}
+	}
+
+	return false
+}
+
+// intersect 는 fs 의 순서를 유지하면서 양쪽 모두 가진 기능만 남긴다.
+func (fs FeatureSet) intersect(other FeatureSet) FeatureSet {
+	result := FeatureSet{}
+
+	for _, f := range fs {
+		if other.Has(f) && !result.Has(f) {
+			result = append(result, f)
+		}
+	}
+
+	return result
+}
+
+// AdvertisedFeatures 는 option 에 따라 handshake 에서 상대방에게 알릴 기능 목록을 만든다.
+func AdvertisedFeatures(opts ConnOpts) FeatureSet {
+	features := FeatureSet{}
+
+	if opts.EncryptionEnabled {
+		features = append(features, FeatureEncryption)
+	}
+
+	if opts.Compression.Enabled {
+		features = append(features, opts.Compression.algorithms()...)
+	}
+
+	if opts.Ack.Enabled {
+		features = append(features, FeatureAck)
+	}
+
+	return features
+}
+
+// Negotiate 는 자신과 상대방의 peer info 로 version 과 기능을 합의한다.
+// 합의한 version 은 둘 중 낮은 version 이며, MinProtocolVersion 보다 낮으면 ErrIncompatibleVersion 을 반환한다.
+func Negotiate(local *PeerInfo, peer *PeerInfo) (*Session, error) {
+
+	version := local.Version
+	if peer.Version < version {
+		version = peer.Version
+	}
+
+	if version < MinProtocolVersion {
+		return nil, fmt.Errorf("%w: local [%d], peer [%d]", ErrIncompatibleVersion, local.Version, peer.Version)
+	}
+
+	return &Session{
+		Version:       version,
+		Features:      local.Features.intersect(peer.Features),
+		PeerProtocols: peer.Protocols,
+	}, nil
+}
@@ -0,0 +1,54 @@
+package bifrost_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	// given
+	local := &bifrost.PeerInfo{
+		Version:  bifrost.ProtocolVersion,
+		Features: bifrost.FeatureSet{bifrost.FeatureEncryption, bifrost.FeatureCompressionGzip},
+	}
+	peer := &bifrost.PeerInfo{
+		Version:   bifrost.ProtocolVersion + 1,
+		Features:  bifrost.FeatureSet{bifrost.FeatureCompressionGzip, bifrost.FeatureAck},
+		Protocols: []string{"chat"},
+	}
+
+	// when
+	session, err := bifrost.Negotiate(local, peer)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, bifrost.ProtocolVersion, session.Version)
+	assert.Equal(t, bifrost.FeatureSet{bifrost.FeatureCompressionGzip}, session.Features)
+	assert.Equal(t, []string{"chat"}, session.PeerProtocols)
+	assert.False(t, session.Encrypted())
+}
+
+func TestNegotiate_whenIncompatibleVersion(t *testing.T) {
+	// given
+	local := &bifrost.PeerInfo{Version: bifrost.ProtocolVersion}
+	peer := &bifrost.PeerInfo{Version: bifrost.MinProtocolVersion - 1}
+
+	// when
+	_, err := bifrost.Negotiate(local, peer)
+
+	// then
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, bifrost.ErrIncompatibleVersion))
+}
+
+func TestAdvertisedFeatures(t *testing.T) {
+	// when
+	features := bifrost.AdvertisedFeatures(bifrost.ConnOpts{EncryptionEnabled: true})
+
+	// then
+	assert.True(t, features.Has(bifrost.FeatureEncryption))
+	assert.False(t, bifrost.AdvertisedFeatures(bifrost.ConnOpts{}).Has(bifrost.FeatureEncryption))
+}
@@ -23,30 +23,95 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+type Compression int32
+
+const (
+	Compression_NO_COMPRESSION Compression = 0
+	Compression_DEFLATE        Compression = 1
+	Compression_GZIP           Compression = 2
+)
+
+var Compression_name = map[int32]string{
+	0: "NO_COMPRESSION",
+	1: "DEFLATE",
+	2: "GZIP",
+}
+var Compression_value = map[string]int32{
+	"NO_COMPRESSION": 0,
+	"DEFLATE":        1,
+	"GZIP":           2,
+}
+
+func (x Compression) String() string {
+	return proto.EnumName(Compression_name, int32(x))
+}
+func (Compression) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_stream_b9e06ed1eb20772e, []int{0}
+}
+
 type Envelope_Type int32
 
 const (
 	Envelope_REQUEST_PEERINFO  Envelope_Type = 0
 	Envelope_RESPONSE_PEERINFO Envelope_Type = 2
 	Envelope_NORMAL            Envelope_Type = 3
+	Envelope_REJECT_PEER       Envelope_Type = 4
+	// payload is a marshalled Envelope signed by its origin and forwarded by the sender
+	Envelope_RELAY Envelope_Type = 5
+	// payload announces the sender's new key, signed by its current key
+	Envelope_ROTATE_KEY Envelope_Type = 6
+	// payload is the reply to the request with the same request_id
+	Envelope_RESPONSE Envelope_Type = 7
+	// payload is a marshalled Chunk of a streamed transfer
+	Envelope_CHUNK Envelope_Type = 8
+	// payload is a marshalled ChunkAck returning flow control credit for a transfer
+	Envelope_CHUNK_ACK Envelope_Type = 9
+	// payload is a heartbeat id the peer echoes in a PONG
+	Envelope_PING Envelope_Type = 10
+	// payload is the heartbeat id of the PING being answered
+	Envelope_PONG Envelope_Type = 11
+	// payload is a marshalled GoAway, the last envelope before the sender closes the connection
+	Envelope_GOAWAY Envelope_Type = 12
+	// payload is a marshalled Ack reporting whether the envelope with the same ack_id was handled
+	Envelope_ACK Envelope_Type = 13
 )
 
 var Envelope_Type_name = map[int32]string{
-	0: "REQUEST_PEERINFO",
-	2: "RESPONSE_PEERINFO",
-	3: "NORMAL",
+	0:  "REQUEST_PEERINFO",
+	2:  "RESPONSE_PEERINFO",
+	3:  "NORMAL",
+	4:  "REJECT_PEER",
+	5:  "RELAY",
+	6:  "ROTATE_KEY",
+	7:  "RESPONSE",
+	8:  "CHUNK",
+	9:  "CHUNK_ACK",
+	10: "PING",
+	11: "PONG",
+	12: "GOAWAY",
+	13: "ACK",
 }
 var Envelope_Type_value = map[string]int32{
 	"REQUEST_PEERINFO":  0,
 	"RESPONSE_PEERINFO": 2,
 	"NORMAL":            3,
+	"REJECT_PEER":       4,
+	"RELAY":             5,
+	"ROTATE_KEY":        6,
+	"RESPONSE":          7,
+	"CHUNK":             8,
+	"CHUNK_ACK":         9,
+	"PING":              10,
+	"PONG":              11,
+	"GOAWAY":            12,
+	"ACK":               13,
 }
 
 func (x Envelope_Type) String() string {
 	return proto.EnumName(Envelope_Type_name, int32(x))
 }
 func (Envelope_Type) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_stream_2e627906fcd402b5, []int{0, 0}
+	return fileDescriptor_stream_b9e06ed1eb20772e, []int{0, 0}
 }
 
 type Envelope struct {
@@ -54,21 +119,35 @@ type Envelope struct {
 	Payload []byte `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`
 	// signed Message
 	Signature []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
-	// sender's public key
+	// public key of the peer that signed this envelope
 	Pubkey []byte `protobuf:"bytes,3,opt,name=pubkey,proto3" json:"pubkey,omitempty"`
 	// message protocol
-	Protocol             string        `protobuf:"bytes,4,opt,name=protocol" json:"protocol,omitempty"`
-	Type                 Envelope_Type `protobuf:"varint,5,opt,name=type,enum=pb.Envelope_Type" json:"type,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
-	XXX_unrecognized     []byte        `json:"-"`
-	XXX_sizecache        int32         `json:"-"`
+	Protocol string        `protobuf:"bytes,4,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Type     Envelope_Type `protobuf:"varint,5,opt,name=type,proto3,enum=pb.Envelope_Type" json:"type,omitempty"`
+	// sequence number, increasing per connection and lane
+	Seq uint64 `protobuf:"varint,6,opt,name=seq,proto3" json:"seq,omitempty"`
+	// send time in unix nano
+	Timestamp int64 `protobuf:"varint,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// payload is encrypted with the session key
+	Encrypted bool `protobuf:"varint,8,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
+	// correlates a RESPONSE with the request it answers, 0 if no response is expected
+	RequestId uint64 `protobuf:"varint,9,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// payload is compressed after signing and must be decompressed before the signature check
+	Compression Compression `protobuf:"varint,10,opt,name=compression,proto3,enum=pb.Compression" json:"compression,omitempty"`
+	// asks the receiver to answer with an ACK once the handler has processed the envelope, 0 if no ACK is expected
+	AckId uint64 `protobuf:"varint,11,opt,name=ack_id,json=ackId,proto3" json:"ack_id,omitempty"`
+	// priority lane the sender queued the envelope in, each lane has its own seq
+	Lane                 uint32   `protobuf:"varint,12,opt,name=lane,proto3" json:"lane,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *Envelope) Reset()         { *m = Envelope{} }
 func (m *Envelope) String() string { return proto.CompactTextString(m) }
 func (*Envelope) ProtoMessage()    {}
 func (*Envelope) Descriptor() ([]byte, []int) {
-	return fileDescriptor_stream_2e627906fcd402b5, []int{0}
+	return fileDescriptor_stream_b9e06ed1eb20772e, []int{0}
 }
 func (m *Envelope) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Envelope.Unmarshal(m, b)
@@ -123,8 +202,324 @@ func (m *Envelope) GetType() Envelope_Type {
 	return Envelope_REQUEST_PEERINFO
 }
 
+func (m *Envelope) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *Envelope) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *Envelope) GetEncrypted() bool {
+	if m != nil {
+		return m.Encrypted
+	}
+	return false
+}
+
+func (m *Envelope) GetRequestId() uint64 {
+	if m != nil {
+		return m.RequestId
+	}
+	return 0
+}
+
+func (m *Envelope) GetCompression() Compression {
+	if m != nil {
+		return m.Compression
+	}
+	return Compression_NO_COMPRESSION
+}
+
+func (m *Envelope) GetAckId() uint64 {
+	if m != nil {
+		return m.AckId
+	}
+	return 0
+}
+
+func (m *Envelope) GetLane() uint32 {
+	if m != nil {
+		return m.Lane
+	}
+	return 0
+}
+
+// part of a payload streamed as a sequence of envelopes
+type Chunk struct {
+	// identifies the transfer, unique per sender and connection
+	TransferId uint64 `protobuf:"varint,1,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`
+	// position of the chunk in the transfer, starting at 0
+	Index uint64 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	Data  []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	// marks the final chunk of the transfer
+	Last bool `protobuf:"varint,4,opt,name=last,proto3" json:"last,omitempty"`
+	// SHA-256 of the whole transfer, set on the final chunk
+	Digest []byte `protobuf:"bytes,5,opt,name=digest,proto3" json:"digest,omitempty"`
+	// reason the sender aborted the transfer, empty otherwise
+	Abort string `protobuf:"bytes,6,opt,name=abort,proto3" json:"abort,omitempty"`
+	// number of chunks the sender sends ahead of ChunkAcks, set on the first chunk
+	Window               uint32   `protobuf:"varint,7,opt,name=window,proto3" json:"window,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Chunk) Reset()         { *m = Chunk{} }
+func (m *Chunk) String() string { return proto.CompactTextString(m) }
+func (*Chunk) ProtoMessage()    {}
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_stream_b9e06ed1eb20772e, []int{1}
+}
+func (m *Chunk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Chunk.Unmarshal(m, b)
+}
+func (m *Chunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Chunk.Marshal(b, m, deterministic)
+}
+func (dst *Chunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Chunk.Merge(dst, src)
+}
+func (m *Chunk) XXX_Size() int {
+	return xxx_messageInfo_Chunk.Size(m)
+}
+func (m *Chunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_Chunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Chunk proto.InternalMessageInfo
+
+func (m *Chunk) GetTransferId() uint64 {
+	if m != nil {
+		return m.TransferId
+	}
+	return 0
+}
+
+func (m *Chunk) GetIndex() uint64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *Chunk) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *Chunk) GetLast() bool {
+	if m != nil {
+		return m.Last
+	}
+	return false
+}
+
+func (m *Chunk) GetDigest() []byte {
+	if m != nil {
+		return m.Digest
+	}
+	return nil
+}
+
+func (m *Chunk) GetAbort() string {
+	if m != nil {
+		return m.Abort
+	}
+	return ""
+}
+
+func (m *Chunk) GetWindow() uint32 {
+	if m != nil {
+		return m.Window
+	}
+	return 0
+}
+
+// sent by the receiver each time it consumes a chunk
+type ChunkAck struct {
+	TransferId uint64 `protobuf:"varint,1,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`
+	// index of the consumed chunk
+	Index uint64 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	// the final chunk was consumed and the digest matched
+	Done bool `protobuf:"varint,3,opt,name=done,proto3" json:"done,omitempty"`
+	// reason the receiver aborted the transfer, empty otherwise
+	Abort                string   `protobuf:"bytes,4,opt,name=abort,proto3" json:"abort,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ChunkAck) Reset()         { *m = ChunkAck{} }
+func (m *ChunkAck) String() string { return proto.CompactTextString(m) }
+func (*ChunkAck) ProtoMessage()    {}
+func (*ChunkAck) Descriptor() ([]byte, []int) {
+	return fileDescriptor_stream_b9e06ed1eb20772e, []int{2}
+}
+func (m *ChunkAck) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChunkAck.Unmarshal(m, b)
+}
+func (m *ChunkAck) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChunkAck.Marshal(b, m, deterministic)
+}
+func (dst *ChunkAck) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChunkAck.Merge(dst, src)
+}
+func (m *ChunkAck) XXX_Size() int {
+	return xxx_messageInfo_ChunkAck.Size(m)
+}
+func (m *ChunkAck) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChunkAck.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChunkAck proto.InternalMessageInfo
+
+func (m *ChunkAck) GetTransferId() uint64 {
+	if m != nil {
+		return m.TransferId
+	}
+	return 0
+}
+
+func (m *ChunkAck) GetIndex() uint64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *ChunkAck) GetDone() bool {
+	if m != nil {
+		return m.Done
+	}
+	return false
+}
+
+func (m *ChunkAck) GetAbort() string {
+	if m != nil {
+		return m.Abort
+	}
+	return ""
+}
+
+// result of handling an envelope that asked for an ACK
+type Ack struct {
+	AckId uint64 `protobuf:"varint,1,opt,name=ack_id,json=ackId,proto3" json:"ack_id,omitempty"`
+	// 0 if the handler processed the envelope, a failure code otherwise
+	Code                 uint32   `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Reason               string   `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Ack) Reset()         { *m = Ack{} }
+func (m *Ack) String() string { return proto.CompactTextString(m) }
+func (*Ack) ProtoMessage()    {}
+func (*Ack) Descriptor() ([]byte, []int) {
+	return fileDescriptor_stream_b9e06ed1eb20772e, []int{3}
+}
+func (m *Ack) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Ack.Unmarshal(m, b)
+}
+func (m *Ack) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Ack.Marshal(b, m, deterministic)
+}
+func (dst *Ack) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Ack.Merge(dst, src)
+}
+func (m *Ack) XXX_Size() int {
+	return xxx_messageInfo_Ack.Size(m)
+}
+func (m *Ack) XXX_DiscardUnknown() {
+	xxx_messageInfo_Ack.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Ack proto.InternalMessageInfo
+
+func (m *Ack) GetAckId() uint64 {
+	if m != nil {
+		return m.AckId
+	}
+	return 0
+}
+
+func (m *Ack) GetCode() uint32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *Ack) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+// reason the sender is closing the connection
+type GoAway struct {
+	Code                 uint32   `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message              string   `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GoAway) Reset()         { *m = GoAway{} }
+func (m *GoAway) String() string { return proto.CompactTextString(m) }
+func (*GoAway) ProtoMessage()    {}
+func (*GoAway) Descriptor() ([]byte, []int) {
+	return fileDescriptor_stream_b9e06ed1eb20772e, []int{4}
+}
+func (m *GoAway) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GoAway.Unmarshal(m, b)
+}
+func (m *GoAway) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GoAway.Marshal(b, m, deterministic)
+}
+func (dst *GoAway) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GoAway.Merge(dst, src)
+}
+func (m *GoAway) XXX_Size() int {
+	return xxx_messageInfo_GoAway.Size(m)
+}
+func (m *GoAway) XXX_DiscardUnknown() {
+	xxx_messageInfo_GoAway.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GoAway proto.InternalMessageInfo
+
+func (m *GoAway) GetCode() uint32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *GoAway) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*Envelope)(nil), "pb.Envelope")
+	proto.RegisterType((*Chunk)(nil), "pb.Chunk")
+	proto.RegisterType((*ChunkAck)(nil), "pb.ChunkAck")
+	proto.RegisterType((*Ack)(nil), "pb.Ack")
+	proto.RegisterType((*GoAway)(nil), "pb.GoAway")
+	proto.RegisterEnum("pb.Compression", Compression_name, Compression_value)
 	proto.RegisterEnum("pb.Envelope_Type", Envelope_Type_name, Envelope_Type_value)
 }
 
@@ -136,8 +531,9 @@ var _ grpc.ClientConn
 // is compatible with the grpc package it is being compiled against.
 const _ = grpc.SupportPackageIsVersion4
 
-// Client API for StreamService service
-
+// StreamServiceClient is the client API for StreamService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type StreamServiceClient interface {
 	BifrostStream(ctx context.Context, opts ...grpc.CallOption) (StreamService_BifrostStreamClient, error)
 }
@@ -151,7 +547,7 @@ func NewStreamServiceClient(cc *grpc.ClientConn) StreamServiceClient {
 }
 
 func (c *streamServiceClient) BifrostStream(ctx context.Context, opts ...grpc.CallOption) (StreamService_BifrostStreamClient, error) {
-	stream, err := grpc.NewClientStream(ctx, &_StreamService_serviceDesc.Streams[0], c.cc, "/pb.StreamService/BifrostStream", opts...)
+	stream, err := c.cc.NewStream(ctx, &_StreamService_serviceDesc.Streams[0], "/pb.StreamService/BifrostStream", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -181,8 +577,7 @@ func (x *streamServiceBifrostStreamClient) Recv() (*Envelope, error) {
 	return m, nil
 }
 
-// Server API for StreamService service
-
+// StreamServiceServer is the server API for StreamService service.
 type StreamServiceServer interface {
 	BifrostStream(StreamService_BifrostStreamServer) error
 }
@@ -232,24 +627,50 @@ var _StreamService_serviceDesc = grpc.ServiceDesc{
 	Metadata: "stream.proto",
 }
 
-func init() { proto.RegisterFile("stream.proto", fileDescriptor_stream_2e627906fcd402b5) }
-
-var fileDescriptor_stream_2e627906fcd402b5 = []byte{
-	// 251 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x8f, 0xd1, 0x4a, 0xc3, 0x30,
-	0x14, 0x86, 0x97, 0xae, 0xd6, 0xed, 0xd0, 0x49, 0x77, 0x50, 0x09, 0xc3, 0x8b, 0x52, 0x10, 0x7a,
-	0x55, 0x74, 0x3e, 0x80, 0x38, 0x88, 0x20, 0x68, 0x3b, 0x93, 0x79, 0x2d, 0xed, 0x8c, 0x52, 0xac,
-	0x4b, 0x48, 0xb3, 0x41, 0x5f, 0xd6, 0x67, 0x11, 0xa3, 0x73, 0xee, 0xf2, 0xfb, 0xbe, 0x8b, 0xf3,
-	0x1f, 0x08, 0x5b, 0x6b, 0x64, 0xf9, 0x91, 0x69, 0xa3, 0xac, 0x42, 0x4f, 0x57, 0xc9, 0x27, 0x81,
-	0x01, 0x5b, 0x6d, 0x64, 0xa3, 0xb4, 0x44, 0x0a, 0x87, 0xba, 0xec, 0x1a, 0x55, 0xbe, 0x50, 0x12,
-	0x93, 0x34, 0xe4, 0x5b, 0xc4, 0x33, 0x18, 0xb6, 0xf5, 0xdb, 0xaa, 0xb4, 0x6b, 0x23, 0xa9, 0xe7,
-	0xda, 0x4e, 0xe0, 0x29, 0x04, 0x7a, 0x5d, 0xbd, 0xcb, 0x8e, 0xf6, 0x5d, 0xfa, 0x25, 0x9c, 0xc0,
-	0xc0, 0x5d, 0x5a, 0xaa, 0x86, 0xfa, 0x31, 0x49, 0x87, 0xfc, 0x8f, 0xf1, 0x1c, 0x7c, 0xdb, 0x69,
-	0x49, 0x0f, 0x62, 0x92, 0x1e, 0x4d, 0xc7, 0x99, 0xae, 0xb2, 0xed, 0x8e, 0x6c, 0xd1, 0x69, 0xc9,
-	0x5d, 0x4e, 0xae, 0xc1, 0xff, 0x26, 0x3c, 0x86, 0x88, 0xb3, 0xc7, 0x27, 0x26, 0x16, 0xcf, 0x73,
-	0xc6, 0xf8, 0x5d, 0x7e, 0x5b, 0x44, 0x3d, 0x3c, 0x81, 0x31, 0x67, 0x62, 0x5e, 0xe4, 0x82, 0xed,
-	0xb4, 0x87, 0x00, 0x41, 0x5e, 0xf0, 0x87, 0x9b, 0xfb, 0xa8, 0x3f, 0x9d, 0xc1, 0x48, 0xb8, 0xa7,
-	0x85, 0x34, 0x9b, 0x7a, 0x29, 0xf1, 0x12, 0x46, 0xb3, 0xfa, 0xd5, 0xa8, 0xd6, 0xfe, 0x78, 0x0c,
-	0xff, 0xdf, 0x9e, 0xec, 0x51, 0xd2, 0x4b, 0xc9, 0x05, 0xa9, 0x02, 0xb7, 0xfa, 0xea, 0x2b, 0x00,
-	0x00, 0xff, 0xff, 0x98, 0x68, 0x10, 0xdc, 0x3f, 0x01, 0x00, 0x00,
+func init() { proto.RegisterFile("stream.proto", fileDescriptor_stream_b9e06ed1eb20772e) }
+
+var fileDescriptor_stream_b9e06ed1eb20772e = []byte{
+	// 665 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0x41, 0x6f, 0xe2, 0x38,
+	0x18, 0xad, 0x21, 0x40, 0xf2, 0x01, 0xad, 0xfb, 0xa9, 0x5d, 0x45, 0xd5, 0xae, 0x16, 0x21, 0xad,
+	0x14, 0xed, 0x01, 0x6d, 0xbb, 0xd2, 0x6a, 0xaf, 0x29, 0xeb, 0x52, 0xb6, 0x6d, 0xc2, 0x18, 0xaa,
+	0x51, 0xe7, 0x82, 0x4c, 0xe2, 0x76, 0x22, 0x20, 0x49, 0x93, 0xd0, 0x0e, 0xbf, 0x68, 0x2e, 0xf3,
+	0x13, 0xe6, 0xc7, 0x8d, 0xec, 0x40, 0x61, 0xe6, 0x3a, 0xb7, 0xf7, 0x9e, 0x3f, 0x3f, 0x3f, 0xcc,
+	0x73, 0xa0, 0x95, 0x17, 0x99, 0x14, 0xcb, 0x5e, 0x9a, 0x25, 0x45, 0x82, 0x95, 0x74, 0xd6, 0xfd,
+	0x6c, 0x80, 0xc9, 0xe2, 0x17, 0xb9, 0x48, 0x52, 0x89, 0x36, 0x34, 0x52, 0xb1, 0x5e, 0x24, 0x22,
+	0xb4, 0x49, 0x87, 0x38, 0x2d, 0xbe, 0xa5, 0xf8, 0x2b, 0x58, 0x79, 0xf4, 0x14, 0x8b, 0x62, 0x95,
+	0x49, 0xbb, 0xa2, 0xd7, 0x76, 0x02, 0xfe, 0x02, 0xf5, 0x74, 0x35, 0x9b, 0xcb, 0xb5, 0x5d, 0xd5,
+	0x4b, 0x1b, 0x86, 0x67, 0x60, 0xea, 0x93, 0x82, 0x64, 0x61, 0x1b, 0x1d, 0xe2, 0x58, 0xfc, 0x8d,
+	0xe3, 0x1f, 0x60, 0x14, 0xeb, 0x54, 0xda, 0xb5, 0x0e, 0x71, 0x0e, 0x2f, 0x8e, 0x7b, 0xe9, 0xac,
+	0xb7, 0xcd, 0xd1, 0x9b, 0xac, 0x53, 0xc9, 0xf5, 0x32, 0x52, 0xa8, 0xe6, 0xf2, 0xd9, 0xae, 0x77,
+	0x88, 0x63, 0x70, 0x05, 0x55, 0x94, 0x22, 0x5a, 0xca, 0xbc, 0x10, 0xcb, 0xd4, 0x6e, 0x74, 0x88,
+	0x53, 0xe5, 0x3b, 0x41, 0xad, 0xca, 0x38, 0xc8, 0xd6, 0x69, 0x21, 0x43, 0xdb, 0xec, 0x10, 0xc7,
+	0xe4, 0x3b, 0x01, 0x7f, 0x03, 0xc8, 0xe4, 0xf3, 0x4a, 0xe6, 0xc5, 0x34, 0x0a, 0x6d, 0x4b, 0x9b,
+	0x5a, 0x1b, 0x65, 0x18, 0xe2, 0x39, 0x34, 0x83, 0x64, 0x99, 0x66, 0x32, 0xcf, 0xa3, 0x24, 0xb6,
+	0x41, 0x47, 0x3b, 0x52, 0xd1, 0xfa, 0x3b, 0x99, 0xef, 0xcf, 0xe0, 0x29, 0xd4, 0x45, 0x30, 0x57,
+	0x6e, 0x4d, 0xed, 0x56, 0x13, 0xc1, 0x7c, 0x18, 0x22, 0x82, 0xb1, 0x10, 0xb1, 0xb4, 0x5b, 0x1d,
+	0xe2, 0xb4, 0xb9, 0xc6, 0xdd, 0xaf, 0x04, 0x0c, 0xf5, 0xcb, 0xf0, 0x04, 0x28, 0x67, 0xef, 0xee,
+	0xd9, 0x78, 0x32, 0x1d, 0x31, 0xc6, 0x87, 0xde, 0x95, 0x4f, 0x0f, 0xf0, 0x14, 0x8e, 0x39, 0x1b,
+	0x8f, 0x7c, 0x6f, 0xcc, 0x76, 0x72, 0x05, 0x01, 0xea, 0x9e, 0xcf, 0xef, 0xdc, 0x5b, 0x5a, 0xc5,
+	0x23, 0x68, 0x72, 0xf6, 0x3f, 0xeb, 0x97, 0xfb, 0xa8, 0x81, 0x16, 0xd4, 0x38, 0xbb, 0x75, 0x1f,
+	0x68, 0x0d, 0x0f, 0x01, 0xb8, 0x3f, 0x71, 0x27, 0x6c, 0x7a, 0xc3, 0x1e, 0x68, 0x1d, 0x5b, 0x60,
+	0x6e, 0xed, 0x68, 0x43, 0x0d, 0xf6, 0xaf, 0xef, 0xbd, 0x1b, 0x6a, 0x62, 0x1b, 0x2c, 0x0d, 0xa7,
+	0x6e, 0xff, 0x86, 0x5a, 0x68, 0x82, 0x31, 0x1a, 0x7a, 0x03, 0x0a, 0x1a, 0xf9, 0xde, 0x80, 0x36,
+	0xd5, 0x99, 0x03, 0xdf, 0x7d, 0xef, 0x3e, 0xd0, 0x16, 0x36, 0xa0, 0xaa, 0x06, 0xdb, 0xdd, 0x2f,
+	0x04, 0x6a, 0xfd, 0x8f, 0xab, 0x78, 0x8e, 0xbf, 0x43, 0xb3, 0xc8, 0x44, 0x9c, 0x3f, 0xca, 0x6c,
+	0x1a, 0x95, 0x55, 0x31, 0x38, 0x6c, 0xa5, 0x61, 0x88, 0x27, 0x50, 0x8b, 0xe2, 0x50, 0x7e, 0xd2,
+	0x4d, 0x31, 0x78, 0x49, 0xd4, 0x9d, 0x84, 0xa2, 0x10, 0x9b, 0x8e, 0x68, 0x5c, 0xde, 0x53, 0x5e,
+	0xe8, 0x76, 0x98, 0x5c, 0x63, 0xd5, 0xa6, 0x30, 0x7a, 0x92, 0x79, 0xa1, 0xbb, 0xd1, 0xe2, 0x1b,
+	0xa6, 0x5c, 0xc5, 0x2c, 0xc9, 0x0a, 0x5d, 0x06, 0x8b, 0x97, 0x44, 0x4d, 0xbf, 0x46, 0x71, 0x98,
+	0xbc, 0xea, 0x2e, 0xb4, 0xf9, 0x86, 0x75, 0xe7, 0x60, 0xea, 0xb4, 0x6e, 0xf0, 0x53, 0x81, 0x93,
+	0x58, 0xea, 0xc0, 0x26, 0xd7, 0x78, 0x17, 0xc2, 0xd8, 0x0b, 0xd1, 0xbd, 0x86, 0xaa, 0x3a, 0x67,
+	0x57, 0x06, 0xf2, 0x43, 0x19, 0x82, 0x24, 0x2c, 0xdf, 0x4d, 0x9b, 0x6b, 0xac, 0x62, 0x67, 0x52,
+	0xe4, 0x49, 0xac, 0xdd, 0x2d, 0xbe, 0x61, 0xdd, 0x7f, 0xa0, 0x3e, 0x48, 0xdc, 0x57, 0xb1, 0x7e,
+	0xdb, 0x45, 0xf6, 0x76, 0xd9, 0xd0, 0x58, 0xca, 0x3c, 0x17, 0x4f, 0xa5, 0x99, 0xc5, 0xb7, 0xf4,
+	0xcf, 0x7f, 0xa1, 0xb9, 0xd7, 0x51, 0x44, 0x38, 0xf4, 0xfc, 0x69, 0xdf, 0xbf, 0x1b, 0x71, 0x36,
+	0x1e, 0x0f, 0x7d, 0x8f, 0x1e, 0x60, 0x13, 0x1a, 0xff, 0xb1, 0xab, 0x5b, 0x77, 0xc2, 0x28, 0x51,
+	0x7f, 0xf6, 0xe0, 0xc3, 0x70, 0x44, 0x2b, 0x17, 0x97, 0xd0, 0x1e, 0xeb, 0xaf, 0xc2, 0x58, 0x66,
+	0x2f, 0x51, 0x20, 0xf1, 0x1c, 0xda, 0x97, 0xd1, 0x63, 0x96, 0xe4, 0x45, 0xa9, 0x63, 0x6b, 0xff,
+	0x71, 0x9e, 0x7d, 0xc7, 0xba, 0x07, 0x0e, 0xf9, 0x8b, 0xcc, 0xea, 0xfa, 0x59, 0xff, 0xfd, 0x6d,
+	0x00, 0x28, 0x39, 0x84, 0x20, 0x60, 0x04, 0x00, 0x00,
 }
@@ -0,0 +1,75 @@
+package bifrost
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrTooManyInvalidMessages = errors.New("too many invalid messages")
+
+// InvalidMessageAction 은 서명 검증에 실패한 envelope 을 받았을 때의 처리 방법이다.
+type InvalidMessageAction int
+
+const (
+	// envelope 을 버리고 handler 의 ServeError 로 알린다. (기본값)
+	InvalidMessageDropAndReport InvalidMessageAction = iota
+	// envelope 을 알리지 않고 버린다.
+	InvalidMessageDrop
+	// envelope 을 버리고 알리며, Window 동안 MaxFailures 번 실패하면 연결을 끊는다.
+	InvalidMessageDisconnect
+)
+
+const (
+	defaultMaxInvalidMessages   = 3
+	defaultInvalidMessageWindow = time.Minute
+)
+
+// InvalidMessagePolicy 는 서명 검증에 실패한 envelope 의 처리 정책이다.
+type InvalidMessagePolicy struct {
+	Action InvalidMessageAction
+	// InvalidMessageDisconnect 에서 연결을 끊는 실패 횟수. 기본값은 3.
+	MaxFailures int
+	// 실패 횟수를 세는 시간 범위. 기본값은 1분.
+	Window time.Duration
+}
+
+// InvalidMessageError 는 서명 검증에 실패한 envelope 을 보낸 peer 와 protocol 을 알려준다.
+type InvalidMessageError struct {
+	KeyID    KeyID
+	Protocol string
+}
+
+func (e *InvalidMessageError) Error() string {
+	return fmt.Sprintf("invalid message signature from [%s] on protocol [%s]", e.KeyID, e.Protocol)
+}
+
+// failureCounter 는 최근 window 동안 발생한 실패 횟수를 센다.
+type failureCounter struct {
+	window   time.Duration
+	failures []time.Time
+}
+
+func newFailureCounter(window time.Duration) *failureCounter {
+
+	if window == 0 {
+		window = defaultInvalidMessageWindow
+	}
+
+	return &failureCounter{window: window}
+}
+
+// add 는 실패를 기록하고 window 안의 실패 횟수를 반환한다.
+func (c *failureCounter) add(now time.Time) int {
+
+	valid := c.failures[:0]
+	for _, t := range c.failures {
+		if now.Sub(t) < c.window {
+			valid = append(valid, t)
+		}
+	}
+
+	c.failures = append(valid, now)
+
+	return len(c.failures)
+}
@@ -0,0 +1,22 @@
+package bifrost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailureCounter_add(t *testing.T) {
+	// given
+	now := time.Now()
+	counter := newFailureCounter(time.Minute)
+
+	// when
+	counter.add(now.Add(-2 * time.Minute))
+	counter.add(now.Add(-30 * time.Second))
+	count := counter.add(now)
+
+	// then
+	assert.Equal(t, 2, count)
+}
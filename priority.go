@@ -0,0 +1,121 @@
+package bifrost
+
+// Priority 는 보내는 메세지의 우선순위. 우선순위마다 별도의 전송 대기열(lane)을 사용한다.
+type Priority int
+
+const (
+	// 합의 투표, 제어 메세지처럼 지연에 민감한 메세지
+	PriorityHigh Priority = iota
+	// 우선순위를 지정하지 않은 protocol 의 기본값
+	PriorityNormal
+	// block sync 처럼 양이 많고 지연에 둔감한 메세지
+	PriorityLow
+
+	priorityCount = 3
+)
+
+const defaultLaneSize = 200
+
+// lane 이 모두 메세지를 가지고 있을 때 한 번에 보내는 메세지 수의 비율
+var defaultPriorityWeights = map[Priority]int{
+	PriorityHigh:   8,
+	PriorityNormal: 4,
+	PriorityLow:    1,
+}
+
+// outboundQueue 는 우선순위별 lane 을 weighted round robin 으로 꺼낸다.
+// 높은 우선순위의 lane 부터 weight 만큼 보내고, 모든 lane 이 weight 를 다 쓰면 다시 채운다.
+// 높은 우선순위 메세지는 대량의 낮은 우선순위 메세지 뒤에서 기다리지 않고,
+// 낮은 우선순위 메세지도 굶지 않는다.
+type outboundQueue struct {
+	lanes   [priorityCount]chan *innerMessage
+	weights [priorityCount]int
+	// writeStream 만 사용한다.
+	credits [priorityCount]int
+	// 대기열이 비면 신호를 보낸다.
+	idle chan struct{}
+}
+
+// weights 에서 지정하지 않은 우선순위는 기본 weight 를 사용한다.
+func newOutboundQueue(size int, weights map[Priority]int) *outboundQueue {
+
+	q := &outboundQueue{
+		idle: make(chan struct{}, 1),
+	}
+
+	for i := range q.lanes {
+		q.lanes[i] = make(chan *innerMessage, size)
+
+		weight, ok := weights[Priority(i)]
+		if !ok || weight <= 0 {
+			weight = defaultPriorityWeights[Priority(i)]
+		}
+
+		q.weights[i] = weight
+		q.credits[i] = weight
+	}
+
+	return q
+}
+
+func (q *outboundQueue) lane(priority Priority) chan *innerMessage {
+
+	if priority < 0 || priority >= priorityCount {
+		priority = PriorityNormal
+	}
+
+	return q.lanes[priority]
+}
+
+// poll 은 다음에 보낼 메세지를 기다리지 않고 꺼낸다.
+func (q *outboundQueue) poll() (*innerMessage, bool) {
+
+	if m, ok := q.pollWithCredit(); ok {
+		return m, true
+	}
+
+	// weight 가 남은 lane 에 메세지가 없으면 weight 를 다시 채운다.
+	q.credits = q.weights
+
+	return q.pollWithCredit()
+}
+
+func (q *outboundQueue) pollWithCredit() (*innerMessage, bool) {
+
+	for i := range q.lanes {
+		if q.credits[i] == 0 {
+			continue
+		}
+
+		select {
+		case m := <-q.lanes[i]:
+			q.credits[i]--
+			return m, true
+		default:
+		}
+	}
+
+	return nil, false
+}
+
+func (q *outboundQueue) len() int {
+
+	n := 0
+	for _, lane := range q.lanes {
+		n += len(lane)
+	}
+
+	return n
+}
+
+func (q *outboundQueue) notifyIfIdle() {
+
+	if q.len() != 0 {
+		return
+	}
+
+	select {
+	case q.idle <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,74 @@
+package bifrost
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/bifrost/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPriorityMessage(protocol string) *innerMessage {
+	return &innerMessage{Envelope: &pb.Envelope{Protocol: protocol}}
+}
+
+func TestOutboundQueue_poll(t *testing.T) {
+	// given
+	q := newOutboundQueue(10, nil)
+
+	for i := 0; i < 5; i++ {
+		q.lane(PriorityLow) <- newPriorityMessage("block")
+	}
+	q.lane(PriorityHigh) <- newPriorityMessage("vote")
+
+	// when
+	m, ok := q.poll()
+
+	// then
+	assert.True(t, ok)
+	assert.Equal(t, "vote", m.Envelope.Protocol)
+}
+
+func TestOutboundQueue_poll_whenAllLanesBusy(t *testing.T) {
+	// given
+	q := newOutboundQueue(20, map[Priority]int{PriorityHigh: 2, PriorityNormal: 1})
+
+	for i := 0; i < 10; i++ {
+		q.lane(PriorityHigh) <- newPriorityMessage("high")
+		q.lane(PriorityNormal) <- newPriorityMessage("normal")
+		q.lane(PriorityLow) <- newPriorityMessage("low")
+	}
+
+	// when
+	var protocols []string
+	for i := 0; i < 8; i++ {
+		m, ok := q.poll()
+		assert.True(t, ok)
+		protocols = append(protocols, m.Envelope.Protocol)
+	}
+
+	// then
+	assert.Equal(t, []string{"high", "high", "normal", "low", "high", "high", "normal", "low"}, protocols)
+}
+
+func TestOutboundQueue_poll_whenEmpty(t *testing.T) {
+	// given
+	q := newOutboundQueue(10, nil)
+
+	// when
+	_, ok := q.poll()
+
+	// then
+	assert.False(t, ok)
+	assert.Equal(t, 0, q.len())
+}
+
+func TestGrpcConnection_priorityOf(t *testing.T) {
+	// given
+	conn := &GrpcConnection{priorities: map[string]Priority{"vote": PriorityHigh, "block": PriorityLow}}
+
+	// then
+	assert.Equal(t, PriorityHigh, conn.priorityOf(&pb.Envelope{Protocol: "vote"}))
+	assert.Equal(t, PriorityLow, conn.priorityOf(&pb.Envelope{Protocol: "block"}))
+	assert.Equal(t, PriorityNormal, conn.priorityOf(&pb.Envelope{Protocol: "tx"}))
+	assert.Equal(t, PriorityHigh, conn.priorityOf(&pb.Envelope{Type: pb.Envelope_ROTATE_KEY, Protocol: "block"}))
+}
@@ -0,0 +1,217 @@
+package bifrost
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/DE-labtory/bifrost/pb"
+)
+
+// RateLimit 은 초당 허용하는 전송량이다. 1초 분량까지 한 번에 몰아서 보낼 수 있다.
+type RateLimit struct {
+	// 초당 payload byte 수. 0 이면 제한하지 않는다.
+	BytesPerSecond int
+	// 초당 메세지 수. 0 이면 제한하지 않는다.
+	MessagesPerSecond int
+}
+
+// RateLimiter 는 byte 수와 메세지 수에 대한 token bucket 이다. 여러 connection 이 공유할 수 있다.
+type RateLimiter struct {
+	bytes    *tokenBucket
+	messages *tokenBucket
+}
+
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	return &RateLimiter{
+		bytes:    newTokenBucket(limit.BytesPerSecond),
+		messages: newTokenBucket(limit.MessagesPerSecond),
+	}
+}
+
+// Wait 은 size byte 메세지 하나를 보낼 수 있을 때까지 기다린다. ctx 가 먼저 끝나면 ctx.Err() 를 반환한다.
+func (l *RateLimiter) Wait(ctx context.Context, size int) error {
+	return throttle(ctx, nil, size, l)
+}
+
+// reserve 는 size byte 메세지 하나만큼 token 을 미리 가져가고, token 이 채워질 때까지 기다려야 하는 시간을 반환한다.
+func (l *RateLimiter) reserve(size int, now time.Time) time.Duration {
+
+	delay := l.bytes.reserve(float64(size), now)
+
+	if d := l.messages.reserve(1, now); d > delay {
+		delay = d
+	}
+
+	return delay
+}
+
+// tokenBucket 은 초당 rate 개의 token 을 채우며 최대 rate 개까지 모아 둔다.
+type tokenBucket struct {
+	sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// rate 가 0 이면 nil 을 반환하며, nil bucket 은 제한하지 않는다.
+func newTokenBucket(rate int) *tokenBucket {
+
+	if rate <= 0 {
+		return nil
+	}
+
+	return &tokenBucket{
+		rate:   float64(rate),
+		tokens: float64(rate),
+		last:   time.Now(),
+	}
+}
+
+// reserve 는 n 개의 token 을 가져간다. token 이 모자라면 빚을 지고, 빚을 갚을 때까지의 시간을 반환한다.
+// 한 번에 rate 보다 큰 요청도 빚을 지고 통과시키므로 큰 메세지가 영원히 막히지 않는다.
+func (b *tokenBucket) reserve(n float64, now time.Time) time.Duration {
+
+	if b == nil {
+		return 0
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.rate {
+			b.tokens = b.rate
+		}
+		b.last = now
+	}
+
+	b.tokens -= n
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// throttle 은 모든 limiter 에서 token 을 가져가고 가장 오래 기다려야 하는 시간만큼 기다린다.
+// nil limiter 는 무시한다. done 이 닫히면 ErrConnClosed 를 반환한다.
+func throttle(ctx context.Context, done <-chan struct{}, size int, limiters ...*RateLimiter) error {
+
+	now := time.Now()
+
+	var delay time.Duration
+	for _, limiter := range limiters {
+		if limiter == nil {
+			continue
+		}
+
+		if d := limiter.reserve(size, now); d > delay {
+			delay = d
+		}
+	}
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		return ErrConnClosed
+	}
+}
+
+// rateLimiters 는 connection 에 적용하는 limiter 이다.
+type rateLimiters struct {
+	send            *RateLimiter
+	receive         *RateLimiter
+	protocolSend    map[string]*RateLimiter
+	protocolReceive map[string]*RateLimiter
+	globalLock      sync.RWMutex
+	globalSend      *RateLimiter
+	globalReceive   *RateLimiter
+}
+
+func newRateLimiters(opts ConnOpts) *rateLimiters {
+
+	limiters := &rateLimiters{
+		send:            newOptionalRateLimiter(opts.SendLimit),
+		receive:         newOptionalRateLimiter(opts.ReceiveLimit),
+		protocolSend:    make(map[string]*RateLimiter),
+		protocolReceive: make(map[string]*RateLimiter),
+	}
+
+	for protocol, limit := range opts.ProtocolSendLimits {
+		limiters.protocolSend[protocol] = newOptionalRateLimiter(limit)
+	}
+
+	for protocol, limit := range opts.ProtocolReceiveLimits {
+		limiters.protocolReceive[protocol] = newOptionalRateLimiter(limit)
+	}
+
+	return limiters
+}
+
+func newOptionalRateLimiter(limit RateLimit) *RateLimiter {
+
+	if limit.BytesPerSecond <= 0 && limit.MessagesPerSecond <= 0 {
+		return nil
+	}
+
+	return NewRateLimiter(limit)
+}
+
+func (l *rateLimiters) global() (*RateLimiter, *RateLimiter) {
+
+	l.globalLock.RLock()
+	defer l.globalLock.RUnlock()
+
+	return l.globalSend, l.globalReceive
+}
+
+// SetGlobalRateLimiters 는 여러 connection 이 공유하는 limiter 를 지정한다. nil 이면 제한하지 않는다.
+func (conn *GrpcConnection) SetGlobalRateLimiters(send *RateLimiter, receive *RateLimiter) {
+
+	conn.limiters.globalLock.Lock()
+	defer conn.limiters.globalLock.Unlock()
+
+	conn.limiters.globalSend = send
+	conn.limiters.globalReceive = receive
+}
+
+// throttleSend 는 connection, protocol, 전체 connection 의 보내기 제한을 기다린다.
+// 서명할 때 timestamp 를 정하므로 대기열에 넣기 전에 보내는 쪽에서 기다려야 받는 쪽의 MaxMessageAge 를 넘기지 않는다.
+func (conn *GrpcConnection) throttleSend(ctx context.Context, protocol string, size int) error {
+
+	globalSend, _ := conn.limiters.global()
+
+	return throttle(ctx, conn.closed, size, conn.limiters.send, conn.limiters.protocolSend[protocol], globalSend)
+}
+
+// throttleReceive 는 받기 제한을 기다린다. serveInbound 에서 기다리므로 read loop 는 제어 메세지를 계속 처리한다.
+func (conn *GrpcConnection) throttleReceive(protocol string, size int) error {
+
+	_, globalReceive := conn.limiters.global()
+
+	return throttle(context.Background(), conn.closed, size, conn.limiters.receive, conn.limiters.protocolReceive[protocol], globalReceive)
+}
+
+// isControl 은 제한하지 않는 제어 메세지인지 확인한다. protocol 은 상대방이 정하므로 envelope 의 type 으로 구분한다.
+func isControl(envelope *pb.Envelope) bool {
+
+	switch envelope.Type {
+	case pb.Envelope_ROTATE_KEY, pb.Envelope_CHUNK_ACK, pb.Envelope_PING, pb.Envelope_PONG, pb.Envelope_GOAWAY, pb.Envelope_ACK:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+package bifrost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket_reserve(t *testing.T) {
+	// given
+	bucket := newTokenBucket(10)
+	now := bucket.last
+
+	// when
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, time.Duration(0), bucket.reserve(1, now))
+	}
+	delay := bucket.reserve(1, now)
+
+	// then
+	assert.Equal(t, 100*time.Millisecond, delay)
+
+	// 채워진 token 으로 빚을 갚으면 다시 기다리지 않는다.
+	assert.Equal(t, time.Duration(0), bucket.reserve(1, now.Add(200*time.Millisecond)))
+}
+
+func TestTokenBucket_reserve_whenLargerThanBurst(t *testing.T) {
+	// given
+	bucket := newTokenBucket(100)
+	now := bucket.last
+
+	// when
+	delay := bucket.reserve(300, now)
+
+	// then
+	assert.Equal(t, 2*time.Second, delay)
+}
+
+func TestTokenBucket_reserve_whenUnlimited(t *testing.T) {
+	// given
+	var bucket *tokenBucket
+
+	// when
+	delay := bucket.reserve(1000, time.Now())
+
+	// then
+	assert.Nil(t, newTokenBucket(0))
+	assert.Equal(t, time.Duration(0), delay)
+}
+
+func TestRateLimiter_reserve(t *testing.T) {
+	// given
+	limiter := NewRateLimiter(RateLimit{BytesPerSecond: 100, MessagesPerSecond: 10})
+	now := time.Now()
+
+	// when
+	byBytes := limiter.reserve(150, now)
+	byMessages := NewRateLimiter(RateLimit{MessagesPerSecond: 1}).reserve(1, now)
+
+	// then
+	assert.True(t, byBytes > 400*time.Millisecond)
+	assert.Equal(t, time.Duration(0), byMessages)
+}
@@ -0,0 +1,269 @@
+package bifrost_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/DE-labtory/bifrost/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	// given
+	limiter := bifrost.NewRateLimiter(bifrost.RateLimit{MessagesPerSecond: 1})
+	assert.NoError(t, limiter.Wait(context.Background(), 10))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// when
+	err := limiter.Wait(ctx, 10)
+
+	// then
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestGrpcConnection_SendLimit(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPair(t, bifrost.ConnOpts{
+		SendLimit: bifrost.RateLimit{MessagesPerSecond: 20},
+	})
+
+	received := make(chan struct{}, 25)
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		received <- struct{}{}
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	start := time.Now()
+
+	// when
+	for i := 0; i < 25; i++ {
+		localConn.Send([]byte("hello"), "test", nil, nil)
+	}
+
+	for i := 0; i < 25; i++ {
+		<-received
+	}
+
+	// then
+	// 1초 분량인 20개는 바로 보내고, 나머지 5개는 50ms 마다 보낸다.
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+}
+
+func TestGrpcConnection_SendLimit_whenMaxMessageAge(t *testing.T) {
+	// given
+	// 제한을 기다리는 1.5초 동안 timestamp 가 바뀌지 않으면 받는 쪽은 오래된 메세지로 거절한다.
+	localConn, remoteConn := newTestConnPairWithOpts(t, bifrost.ConnOpts{
+		SendLimit: bifrost.RateLimit{MessagesPerSecond: 10},
+	}, bifrost.ConnOpts{
+		MaxMessageAge: 500 * time.Millisecond,
+	}, nil)
+
+	received := make(chan struct{}, 25)
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		received <- struct{}{}
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// when
+	for i := 0; i < 25; i++ {
+		localConn.Send([]byte("hello"), "test", nil, nil)
+	}
+
+	// then
+	for i := 0; i < 25; i++ {
+		select {
+		case <-received:
+		case <-time.After(3 * time.Second):
+			t.Fatal("message is rejected as stale")
+		}
+	}
+	assert.Equal(t, uint64(0), remoteConn.Stats().DroppedMessages)
+}
+
+func TestGrpcConnection_ProtocolSendLimit(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPair(t, bifrost.ConnOpts{
+		ProtocolSendLimits: map[string]bifrost.RateLimit{"block": {BytesPerSecond: 10}},
+	})
+
+	received := make(chan string, 2)
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		received <- message.Envelope.Protocol
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	assert.NoError(t, localConn.SendSync([]byte("0123456789"), "block"))
+	<-received
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// when
+	err := localConn.SendContext(ctx, []byte("0123456789"), "block")
+	voteErr := localConn.SendSync([]byte("vote"), "vote")
+
+	// then
+	// 제한된 protocol 만 기다리고 다른 protocol 은 바로 보낸다.
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.NoError(t, voteErr)
+	assert.Equal(t, "vote", <-received)
+}
+
+func TestGrpcConnection_ReceiveLimit(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPairWithOpts(t, bifrost.ConnOpts{}, bifrost.ConnOpts{
+		ReceiveLimit: bifrost.RateLimit{MessagesPerSecond: 20},
+	}, nil)
+
+	received := make(chan struct{}, 25)
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		received <- struct{}{}
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	start := time.Now()
+
+	// when
+	for i := 0; i < 25; i++ {
+		localConn.Send([]byte("hello"), "test", nil, nil)
+	}
+
+	for i := 0; i < 25; i++ {
+		<-received
+	}
+
+	// then
+	// 받은 메세지는 버리지 않고 늦게 처리한다.
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+	assert.Equal(t, uint64(0), remoteConn.Stats().DroppedMessages)
+}
+
+func TestGrpcConnection_ReceiveLimit_whenEmptyProtocol(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPairWithOpts(t, bifrost.ConnOpts{}, bifrost.ConnOpts{
+		ReceiveLimit: bifrost.RateLimit{MessagesPerSecond: 20},
+	}, nil)
+
+	received := make(chan struct{}, 25)
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		received <- struct{}{}
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	start := time.Now()
+
+	// when
+	// protocol 을 비워도 제어 메세지가 아니므로 제한한다.
+	for i := 0; i < 25; i++ {
+		localConn.Send([]byte("hello"), "", nil, nil)
+	}
+
+	for i := 0; i < 25; i++ {
+		<-received
+	}
+
+	// then
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+}
+
+func TestGrpcConnection_ReceiveLimit_whenHeartbeat(t *testing.T) {
+	// given
+	// 받는 쪽이 제한을 기다리는 2초보다 짧은 1초 동안 응답이 없으면 연결을 끊는다.
+	heartbeat := bifrost.ConnOpts{HeartbeatInterval: 100 * time.Millisecond, MaxMissedHeartbeats: 10}
+	limited := heartbeat
+	limited.ReceiveLimit = bifrost.RateLimit{MessagesPerSecond: 10}
+	localConn, remoteConn := newTestConnPairWithOpts(t, heartbeat, limited, nil)
+
+	received := make(chan struct{}, 30)
+	errs := make(chan error, 10)
+	remoteConn.Handle(mocks.MockFuncHandler{
+		RequestFunc: func(message bifrost.Message) {
+			received <- struct{}{}
+		},
+		ErrorFunc: func(conn bifrost.Connection, err error) {
+			errs <- err
+		},
+	})
+	localConn.Handle(mocks.MockFuncHandler{ErrorFunc: func(conn bifrost.Connection, err error) {
+		errs <- err
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// when
+	for i := 0; i < 30; i++ {
+		localConn.Send([]byte("hello"), "test", nil, nil)
+	}
+
+	for i := 0; i < 30; i++ {
+		select {
+		case <-received:
+		case err := <-errs:
+			t.Fatal(err)
+		}
+	}
+
+	// then
+	assert.True(t, remoteConn.RTT() > 0)
+	assert.NoError(t, localConn.SendSync([]byte("hello"), "test"))
+}
+
+func TestConnectionStore_SetRateLimit(t *testing.T) {
+	// given
+	testConnStore := bifrost.NewConnectionStore()
+	testConnStore.SetRateLimit(bifrost.RateLimit{MessagesPerSecond: 20}, bifrost.RateLimit{})
+
+	firstConn, firstRemote := newTestConnPair(t, bifrost.ConnOpts{})
+	secondConn, secondRemote := newTestConnPair(t, bifrost.ConnOpts{})
+	assert.NoError(t, testConnStore.AddConnection(firstConn))
+	assert.NoError(t, testConnStore.AddConnection(secondConn))
+
+	received := make(chan struct{}, 26)
+	handler := mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		received <- struct{}{}
+	}}
+	firstRemote.Handle(handler)
+	secondRemote.Handle(handler)
+
+	for _, conn := range []bifrost.Connection{firstConn, firstRemote, secondConn, secondRemote} {
+		go conn.Start()
+		defer conn.Close()
+	}
+
+	start := time.Now()
+
+	// when
+	for i := 0; i < 13; i++ {
+		firstConn.Send([]byte("hello"), "test", nil, nil)
+		secondConn.Send([]byte("hello"), "test", nil, nil)
+	}
+
+	for i := 0; i < 26; i++ {
+		<-received
+	}
+
+	// then
+	// 두 connection 이 20개를 나누어 쓰므로 나머지 6개는 50ms 마다 보낸다.
+	assert.True(t, time.Since(start) >= 250*time.Millisecond)
+}
@@ -0,0 +1,126 @@
+package bifrost
+
+import (
+	"crypto/sha256"
+	"errors"
+	"time"
+)
+
+var ErrDuplicateMessage = errors.New("duplicate message")
+var ErrMessageOutOfWindow = errors.New("message out of replay window")
+var ErrStaleMessage = errors.New("stale message")
+
+const (
+	defaultReplayWindow  = 1024
+	defaultMaxMessageAge = time.Minute
+	// 전달(relay)된 envelope 을 기억하는 최대 수
+	defaultRelayHistory = 4096
+)
+
+// replayGuard 는 수신한 envelope 의 sequence number 와 timestamp 를 검사해 재전송(replay)된 envelope 을 거절한다.
+// 가장 큰 sequence number 로부터 window 범위 안의 envelope 은 순서가 바뀌어 도착해도 한 번씩 받아들인다.
+type replayGuard struct {
+	window  uint64
+	maxAge  time.Duration
+	highest uint64
+	// seen[seq % window] 에는 그 자리에 마지막으로 받은 sequence number 가 저장된다.
+	seen []uint64
+}
+
+func newReplayGuard(window uint64, maxAge time.Duration) *replayGuard {
+
+	if window == 0 {
+		window = defaultReplayWindow
+	}
+
+	if maxAge == 0 {
+		maxAge = defaultMaxMessageAge
+	}
+
+	return &replayGuard{
+		window: window,
+		maxAge: maxAge,
+		seen:   make([]uint64, window),
+	}
+}
+
+// check 는 envelope 을 받아들일 수 있으면 기록하고 nil 을 반환한다.
+// sequence number 는 1 부터 시작하며, timestamp 는 현재 시간과 maxAge 이상 차이나면(미래 포함) 거절한다.
+func (g *replayGuard) check(seq uint64, timestamp int64, now time.Time) error {
+
+	age := now.Sub(time.Unix(0, timestamp))
+	if age > g.maxAge || age < -g.maxAge {
+		return ErrStaleMessage
+	}
+
+	if seq == 0 || (g.highest >= g.window && seq <= g.highest-g.window) {
+		return ErrMessageOutOfWindow
+	}
+
+	slot := seq % g.window
+
+	if seq <= g.highest && g.seen[slot] == seq {
+		return ErrDuplicateMessage
+	}
+
+	g.seen[slot] = seq
+
+	if seq > g.highest {
+		g.highest = seq
+	}
+
+	return nil
+}
+
+// relayGuard 는 전달(relay)된 envelope 을 작성자별로 기억해서 중간 peer 가 같은 envelope 을 다시 전달하면 거절한다.
+// 작성자의 sequence number 는 작성자와 처음 전달한 peer 사이의 연결 기준이므로, 서명 대상 전체의 hash 로 envelope 을 구분한다.
+// maxAge 가 지난 envelope 은 timestamp 검사로 거절되므로 그때까지만 기억한다.
+type relayGuard struct {
+	maxAge   time.Duration
+	capacity int
+	// 작성자 key ID 와 envelope hash 별로 기억을 끝내는 시간
+	seen  map[string]time.Time
+	order []string
+}
+
+func newRelayGuard(maxAge time.Duration) *relayGuard {
+
+	if maxAge == 0 {
+		maxAge = defaultMaxMessageAge
+	}
+
+	return &relayGuard{
+		maxAge:   maxAge,
+		capacity: defaultRelayHistory,
+		seen:     make(map[string]time.Time),
+	}
+}
+
+// check 는 처음 전달된 envelope 이면 기록하고 nil 을 반환한다.
+// 기억하는 수가 capacity 를 넘으면 가장 먼저 기록한 envelope 부터 잊는다.
+func (g *relayGuard) check(origin KeyID, signingBytes []byte, timestamp int64, now time.Time) error {
+
+	for len(g.order) > 0 && (len(g.order) >= g.capacity || !now.Before(g.seen[g.order[0]])) {
+		delete(g.seen, g.order[0])
+		g.order = g.order[1:]
+	}
+
+	sent := time.Unix(0, timestamp)
+
+	age := now.Sub(sent)
+	if age > g.maxAge || age < -g.maxAge {
+		return ErrStaleMessage
+	}
+
+	digest := sha256.Sum256(signingBytes)
+	id := origin + "/" + string(digest[:])
+
+	if until, ok := g.seen[id]; ok && now.Before(until) {
+		return ErrDuplicateMessage
+	}
+
+	g.seen[id] = sent.Add(g.maxAge)
+	g.order = append(g.order, id)
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package bifrost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayGuard_check(t *testing.T) {
+	// given
+	now := time.Now()
+	guard := newReplayGuard(4, time.Minute)
+
+	// when, then
+	assert.NoError(t, guard.check(1, now.UnixNano(), now))
+	assert.NoError(t, guard.check(3, now.UnixNano(), now))
+	assert.NoError(t, guard.check(2, now.UnixNano(), now))
+	assert.Equal(t, ErrDuplicateMessage, guard.check(2, now.UnixNano(), now))
+	assert.Equal(t, ErrDuplicateMessage, guard.check(3, now.UnixNano(), now))
+}
+
+func TestReplayGuard_check_whenOutOfWindow(t *testing.T) {
+	// given
+	now := time.Now()
+	guard := newReplayGuard(4, time.Minute)
+	assert.NoError(t, guard.check(10, now.UnixNano(), now))
+
+	// when, then
+	assert.Equal(t, ErrMessageOutOfWindow, guard.check(6, now.UnixNano(), now))
+	assert.Equal(t, ErrMessageOutOfWindow, guard.check(0, now.UnixNano(), now))
+	assert.NoError(t, guard.check(7, now.UnixNano(), now))
+}
+
+func TestReplayGuard_check_whenStale(t *testing.T) {
+	// given
+	now := time.Now()
+	guard := newReplayGuard(4, time.Minute)
+
+	// when, then
+	assert.Equal(t, ErrStaleMessage, guard.check(1, now.Add(-2*time.Minute).UnixNano(), now))
+	assert.Equal(t, ErrStaleMessage, guard.check(1, now.Add(2*time.Minute).UnixNano(), now))
+	assert.NoError(t, guard.check(1, now.Add(-30*time.Second).UnixNano(), now))
+}
+
+func TestRelayGuard_check(t *testing.T) {
+	// given
+	now := time.Now()
+	guard := newRelayGuard(time.Minute)
+	assert.NoError(t, guard.check("origin", []byte("envelope"), now.UnixNano(), now))
+
+	// when, then
+	assert.Equal(t, ErrDuplicateMessage, guard.check("origin", []byte("envelope"), now.UnixNano(), now.Add(30*time.Second)))
+	assert.NoError(t, guard.check("other", []byte("envelope"), now.UnixNano(), now))
+	assert.NoError(t, guard.check("origin", []byte("other envelope"), now.UnixNano(), now))
+}
+
+func TestRelayGuard_check_whenStale(t *testing.T) {
+	// given
+	now := time.Now()
+	guard := newRelayGuard(time.Minute)
+	assert.NoError(t, guard.check("origin", []byte("envelope"), now.UnixNano(), now))
+
+	// when, then
+	// 기억을 끝낸 뒤에 다시 전달된 envelope 은 timestamp 검사로 거절한다.
+	assert.Equal(t, ErrStaleMessage, guard.check("origin", []byte("envelope"), now.UnixNano(), now.Add(2*time.Minute)))
+	assert.Empty(t, guard.seen)
+	assert.Equal(t, ErrStaleMessage, guard.check("origin", []byte("new"), now.Add(-2*time.Minute).UnixNano(), now))
+}
+
+func TestRelayGuard_check_whenFull(t *testing.T) {
+	// given
+	now := time.Now()
+	guard := newRelayGuard(time.Minute)
+	guard.capacity = 2
+
+	// when
+	assert.NoError(t, guard.check("origin", []byte("1"), now.UnixNano(), now))
+	assert.NoError(t, guard.check("origin", []byte("2"), now.UnixNano(), now))
+	assert.NoError(t, guard.check("origin", []byte("3"), now.UnixNano(), now))
+
+	// then
+	assert.Len(t, guard.seen, 2)
+	assert.NoError(t, guard.check("origin", []byte("1"), now.UnixNano(), now))
+}
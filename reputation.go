@@ -0,0 +1,367 @@
+package bifrost
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+)
+
+// 평판이 기준보다 낮아져 차단된 peer 와 연결하려는 경우 발생하는 에러
+var ErrPeerBanned = errors.New("peer banned")
+
+// ReputationEvent 는 peer 의 평판을 깎는 잘못된 행동이다.
+type ReputationEvent int
+
+const (
+	// 서명 검증이나 복호화에 실패한 envelope
+	EventInvalidSignature ReputationEvent = iota
+	// 해석할 수 없는 envelope
+	EventMalformedMessage
+	// 재전송된 envelope, 처리하지 않는 protocol 등 약속을 어긴 메세지
+	EventProtocolViolation
+	// 완료되지 않은 handshake
+	EventHandshakeFailure
+)
+
+const (
+	defaultReputationThreshold = -100
+	defaultBanDuration         = time.Hour
+	defaultDecayInterval       = time.Minute
+)
+
+var defaultPenalties = map[ReputationEvent]int{
+	EventInvalidSignature:  20,
+	EventMalformedMessage:  10,
+	EventProtocolViolation: 10,
+	EventHandshakeFailure:  10,
+}
+
+// ReputationOpts 는 평판 기준. 값을 지정하지 않은(zero value) field 는 기본값을 사용한다.
+type ReputationOpts struct {
+	// 점수가 이 값 이하가 되면 차단한다. 기본값은 -100.
+	Threshold int
+	// 차단 기간. 기본값은 1시간.
+	BanDuration time.Duration
+	// event 마다 깎는 점수. 지정하지 않은 event 는 기본값을 사용한다.
+	Penalties map[ReputationEvent]int
+	// 깎인 점수가 1점 회복되는 데 걸리는 시간. 기본값은 1분.
+	DecayInterval time.Duration
+}
+
+// Reputation 은 peer 의 key ID 와 IP 별로 점수를 관리하고, 기준보다 낮아진 peer 를 일정 기간 차단한다.
+// IP 는 port 를 제외한 host 기준으로 관리한다. 깎인 점수는 시간이 지나면 회복되며, 다 회복된 peer 는 잊는다.
+type Reputation struct {
+	sync.Mutex
+	opts      ReputationOpts
+	scores    map[string]*score
+	bans      banList
+	lastSweep time.Time
+}
+
+// score 는 updated 시점의 점수이다. 이후에 회복된 점수는 읽을 때 반영한다.
+type score struct {
+	value   int
+	updated time.Time
+}
+
+// banList 는 차단이 끝나는 시간을 담으며, 파일로 저장된다.
+type banList struct {
+	Keys map[KeyID]time.Time
+	IPs  map[string]time.Time
+}
+
+func NewReputation(opts ReputationOpts) *Reputation {
+
+	if opts.Threshold == 0 {
+		opts.Threshold = defaultReputationThreshold
+	}
+
+	if opts.BanDuration == 0 {
+		opts.BanDuration = defaultBanDuration
+	}
+
+	if opts.DecayInterval == 0 {
+		opts.DecayInterval = defaultDecayInterval
+	}
+
+	return &Reputation{
+		opts:      opts,
+		scores:    make(map[string]*score),
+		bans:      newBanList(),
+		lastSweep: time.Now(),
+	}
+}
+
+func newBanList() banList {
+	return banList{
+		Keys: make(map[KeyID]time.Time),
+		IPs:  make(map[string]time.Time),
+	}
+}
+
+// Report 는 peer 의 잘못된 행동을 기록한다. 점수가 기준 이하가 되어 차단되면 true 를 반환한다.
+// handshake 전처럼 key 를 모르는 경우 keyID 는 빈 문자열이다.
+func (r *Reputation) Report(keyID KeyID, address string, event ReputationEvent) bool {
+
+	r.Lock()
+	defer r.Unlock()
+
+	penalty, ok := r.opts.Penalties[event]
+	if !ok {
+		penalty = defaultPenalties[event]
+	}
+
+	now := time.Now()
+	r.sweep(now)
+
+	banned := false
+
+	for _, id := range scoreIDs(keyID, address) {
+		s, ok := r.scores[id]
+		if !ok {
+			s = &score{updated: now}
+			r.scores[id] = s
+		}
+
+		r.decay(s, now)
+		s.value -= penalty
+
+		if s.value <= r.opts.Threshold {
+			banned = true
+		}
+	}
+
+	if banned {
+		r.ban(keyID, address, r.opts.BanDuration)
+	}
+
+	return banned
+}
+
+// ReportConnection 은 연결된 peer 의 잘못된 행동을 기록하고, 차단되면 연결을 끊는다.
+// r 이 nil 이면 아무것도 하지 않는다.
+func (r *Reputation) ReportConnection(conn Connection, event ReputationEvent) bool {
+
+	if r == nil || !r.Report(conn.GetID(), conn.GetIP().IP, event) {
+		return false
+	}
+
+	conn.Close()
+
+	return true
+}
+
+// Score 는 key ID 의 현재 점수를 반환한다.
+func (r *Reputation) Score(keyID KeyID) int {
+
+	r.Lock()
+	defer r.Unlock()
+
+	s, ok := r.scores[keyScoreID(keyID)]
+	if !ok {
+		return 0
+	}
+
+	r.decay(s, time.Now())
+
+	return s.value
+}
+
+// decay 는 마지막으로 갱신한 뒤 지난 시간만큼 점수를 회복한다. 점수는 0 보다 커지지 않는다.
+func (r *Reputation) decay(s *score, now time.Time) {
+
+	recovered := now.Sub(s.updated) / r.opts.DecayInterval
+	if recovered <= 0 {
+		return
+	}
+
+	s.updated = s.updated.Add(recovered * r.opts.DecayInterval)
+	s.value += int(recovered)
+
+	if s.value > 0 {
+		s.value = 0
+	}
+}
+
+// sweep 은 점수를 다 회복한 peer 와 차단이 끝난 peer 를 잊는다.
+// handshake 에 실패한 주소처럼 다시 보지 않을 peer 가 쌓이지 않도록 DecayInterval 마다 전체를 확인한다.
+func (r *Reputation) sweep(now time.Time) {
+
+	if now.Sub(r.lastSweep) < r.opts.DecayInterval {
+		return
+	}
+
+	r.lastSweep = now
+
+	for id, s := range r.scores {
+		r.decay(s, now)
+		if s.value == 0 {
+			delete(r.scores, id)
+		}
+	}
+
+	for keyID, until := range r.bans.Keys {
+		if !now.Before(until) {
+			delete(r.bans.Keys, keyID)
+		}
+	}
+
+	for host, until := range r.bans.IPs {
+		if !now.Before(until) {
+			delete(r.bans.IPs, host)
+		}
+	}
+}
+
+// Ban 은 key ID 와 IP 를 duration 동안 차단한다. 빈 값은 무시한다.
+func (r *Reputation) Ban(keyID KeyID, address string, duration time.Duration) {
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.ban(keyID, address, duration)
+}
+
+func (r *Reputation) ban(keyID KeyID, address string, duration time.Duration) {
+
+	until := time.Now().Add(duration)
+
+	if keyID != "" {
+		r.bans.Keys[keyID] = until
+	}
+
+	if host := hostOf(address); host != "" {
+		r.bans.IPs[host] = until
+	}
+
+	// 차단이 끝나면 다시 기본 점수부터 시작한다.
+	for _, id := range scoreIDs(keyID, address) {
+		delete(r.scores, id)
+	}
+}
+
+// Unban 은 key ID 와 IP 의 차단을 해제한다.
+func (r *Reputation) Unban(keyID KeyID, address string) {
+
+	r.Lock()
+	defer r.Unlock()
+
+	delete(r.bans.Keys, keyID)
+	delete(r.bans.IPs, hostOf(address))
+}
+
+// IsBanned 는 key ID 나 IP 중 하나라도 차단되어 있는지 확인한다.
+func (r *Reputation) IsBanned(keyID KeyID, address string) bool {
+
+	r.Lock()
+	defer r.Unlock()
+
+	now := time.Now()
+
+	if until, ok := r.bans.Keys[keyID]; ok && keyID != "" {
+		if now.Before(until) {
+			return true
+		}
+		delete(r.bans.Keys, keyID)
+	}
+
+	host := hostOf(address)
+	if until, ok := r.bans.IPs[host]; ok && host != "" {
+		if now.Before(until) {
+			return true
+		}
+		delete(r.bans.IPs, host)
+	}
+
+	return false
+}
+
+// CheckPeer 는 차단된 peer 이면 ErrPeerBanned 를 반환한다. r 이 nil 이면 항상 nil 을 반환한다.
+func (r *Reputation) CheckPeer(keyID KeyID, address string) error {
+
+	if r == nil || !r.IsBanned(keyID, address) {
+		return nil
+	}
+
+	return ErrPeerBanned
+}
+
+// Save 는 차단 목록을 파일로 저장한다.
+func (r *Reputation) Save(path string) error {
+
+	r.Lock()
+	data, err := json.Marshal(r.bans)
+	r.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// Load 는 파일에 저장된 차단 목록을 불러와 현재 목록에 더한다. 이미 끝난 차단은 무시한다.
+func (r *Reputation) Load(path string) error {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	loaded := newBanList()
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return err
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	now := time.Now()
+
+	for keyID, until := range loaded.Keys {
+		if now.Before(until) {
+			r.bans.Keys[keyID] = until
+		}
+	}
+
+	for host, until := range loaded.IPs {
+		if now.Before(until) {
+			r.bans.IPs[host] = until
+		}
+	}
+
+	return nil
+}
+
+func scoreIDs(keyID KeyID, address string) []string {
+
+	ids := make([]string, 0, 2)
+
+	if keyID != "" {
+		ids = append(ids, keyScoreID(keyID))
+	}
+
+	if host := hostOf(address); host != "" {
+		ids = append(ids, "ip/"+host)
+	}
+
+	return ids
+}
+
+func keyScoreID(keyID KeyID) string {
+	return "key/" + keyID
+}
+
+// hostOf 는 "host:port" 형식의 주소에서 host 를 꺼낸다. port 가 없으면 주소를 그대로 사용한다.
+func hostOf(address string) string {
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+
+	return host
+}
@@ -0,0 +1,30 @@
+package bifrost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReputation_sweep(t *testing.T) {
+	// given
+	reputation := NewReputation(ReputationOpts{DecayInterval: time.Minute})
+
+	for _, address := range []string{"127.0.0.1:1234", "127.0.0.2:1234", "127.0.0.3:1234"} {
+		reputation.Report("", address, EventHandshakeFailure)
+	}
+	reputation.Report("key1", "127.0.0.4:1234", EventInvalidSignature)
+	reputation.Ban("key2", "127.0.0.5:1234", time.Minute)
+	assert.Len(t, reputation.scores, 5)
+
+	// when
+	reputation.sweep(time.Now().Add(15 * time.Minute))
+
+	// then
+	// 다 회복한 주소와 차단이 끝난 peer 는 잊고, 아직 회복하지 못한 peer 만 남는다.
+	assert.Len(t, reputation.scores, 2)
+	assert.Equal(t, -5, reputation.scores[keyScoreID("key1")].value)
+	assert.Empty(t, reputation.bans.Keys)
+	assert.Empty(t, reputation.bans.IPs)
+}
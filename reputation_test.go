@@ -0,0 +1,128 @@
+package bifrost_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReputation_Report(t *testing.T) {
+	// given
+	reputation := bifrost.NewReputation(bifrost.ReputationOpts{Threshold: -30})
+
+	// when
+	banned := reputation.Report("key1", "127.0.0.1:1234", bifrost.EventMalformedMessage)
+
+	// then
+	assert.False(t, banned)
+	assert.Equal(t, -10, reputation.Score("key1"))
+	assert.False(t, reputation.IsBanned("key1", "127.0.0.1:1234"))
+
+	// when
+	banned = reputation.Report("key1", "127.0.0.1:1234", bifrost.EventInvalidSignature)
+
+	// then
+	assert.True(t, banned)
+	assert.True(t, reputation.IsBanned("key1", ""))
+	// port 가 달라도 같은 IP 는 차단된다.
+	assert.True(t, reputation.IsBanned("other key", "127.0.0.1:5678"))
+	assert.Equal(t, bifrost.ErrPeerBanned, reputation.CheckPeer("key1", ""))
+	assert.False(t, reputation.IsBanned("other key", "127.0.0.2:1234"))
+}
+
+func TestReputation_Report_whenCustomPenalty(t *testing.T) {
+	// given
+	reputation := bifrost.NewReputation(bifrost.ReputationOpts{
+		Threshold: -5,
+		Penalties: map[bifrost.ReputationEvent]int{bifrost.EventHandshakeFailure: 5},
+	})
+
+	// when
+	banned := reputation.Report("", "127.0.0.1:1234", bifrost.EventHandshakeFailure)
+
+	// then
+	assert.True(t, banned)
+	assert.True(t, reputation.IsBanned("", "127.0.0.1"))
+}
+
+func TestReputation_Score_whenDecayed(t *testing.T) {
+	// given
+	reputation := bifrost.NewReputation(bifrost.ReputationOpts{Threshold: -30, DecayInterval: 10 * time.Millisecond})
+	reputation.Report("key1", "127.0.0.1:1234", bifrost.EventInvalidSignature)
+
+	// when
+	time.Sleep(100 * time.Millisecond)
+	recovered := reputation.Score("key1")
+
+	time.Sleep(200 * time.Millisecond)
+	banned := reputation.Report("key1", "127.0.0.1:1234", bifrost.EventInvalidSignature)
+
+	// then
+	// 가끔 발생하는 실패는 쌓여서 차단으로 이어지지 않는다.
+	assert.True(t, recovered > -20)
+	assert.False(t, banned)
+	assert.Equal(t, -20, reputation.Score("key1"))
+}
+
+func TestReputation_Ban_whenExpired(t *testing.T) {
+	// given
+	reputation := bifrost.NewReputation(bifrost.ReputationOpts{})
+
+	// when
+	reputation.Ban("key1", "127.0.0.1:1234", 10*time.Millisecond)
+	assert.True(t, reputation.IsBanned("key1", ""))
+
+	time.Sleep(20 * time.Millisecond)
+
+	// then
+	assert.False(t, reputation.IsBanned("key1", "127.0.0.1:1234"))
+}
+
+func TestReputation_Unban(t *testing.T) {
+	// given
+	reputation := bifrost.NewReputation(bifrost.ReputationOpts{})
+	reputation.Ban("key1", "127.0.0.1:1234", time.Hour)
+
+	// when
+	reputation.Unban("key1", "127.0.0.1:1234")
+
+	// then
+	assert.False(t, reputation.IsBanned("key1", "127.0.0.1:1234"))
+}
+
+func TestReputation_SaveAndLoad(t *testing.T) {
+	// given
+	path := "./.test_ban_list"
+	defer os.RemoveAll(path)
+
+	reputation := bifrost.NewReputation(bifrost.ReputationOpts{})
+	reputation.Ban("key1", "", time.Hour)
+	reputation.Ban("", "127.0.0.1:1234", time.Hour)
+	reputation.Ban("expired", "", -time.Hour)
+
+	// when
+	assert.NoError(t, reputation.Save(path))
+
+	loaded := bifrost.NewReputation(bifrost.ReputationOpts{})
+	err := loaded.Load(path)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, loaded.IsBanned("key1", ""))
+	assert.True(t, loaded.IsBanned("", "127.0.0.1:7777"))
+	assert.False(t, loaded.IsBanned("expired", ""))
+}
+
+func TestReputation_CheckPeer_whenNil(t *testing.T) {
+	// given
+	var reputation *bifrost.Reputation
+
+	// when
+	err := reputation.CheckPeer("key1", "127.0.0.1:1234")
+
+	// then
+	assert.NoError(t, err)
+}
@@ -0,0 +1,82 @@
+package bifrost
+
+import (
+	"errors"
+	"sync"
+)
+
+// 닫힌 connection 으로 메세지를 보내거나 응답을 기다리는 경우 발생하는 에러
+var ErrConnClosed = errors.New("connection closed")
+
+type requestResult struct {
+	payload []byte
+	err     error
+}
+
+// pendingRequests 는 응답을 기다리는 request 를 request ID 별로 관리한다.
+type pendingRequests struct {
+	sync.Mutex
+	requests map[uint64]chan requestResult
+	closed   bool
+}
+
+func newPendingRequests() *pendingRequests {
+	return &pendingRequests{
+		requests: make(map[uint64]chan requestResult),
+	}
+}
+
+// add 는 응답을 받을 channel 을 등록한다. connection 이 닫힌 뒤에는 ErrConnClosed 를 반환한다.
+func (p *pendingRequests) add(requestID uint64) (chan requestResult, error) {
+
+	p.Lock()
+	defer p.Unlock()
+
+	if p.closed {
+		return nil, ErrConnClosed
+	}
+
+	result := make(chan requestResult, 1)
+	p.requests[requestID] = result
+
+	return result, nil
+}
+
+func (p *pendingRequests) remove(requestID uint64) {
+
+	p.Lock()
+	defer p.Unlock()
+
+	delete(p.requests, requestID)
+}
+
+// resolve 는 기다리는 request 에게 결과를 전달한다. 이미 끝난 request 이면 false 를 반환한다.
+func (p *pendingRequests) resolve(requestID uint64, payload []byte, err error) bool {
+
+	p.Lock()
+	defer p.Unlock()
+
+	result, ok := p.requests[requestID]
+	if !ok {
+		return false
+	}
+
+	delete(p.requests, requestID)
+	result <- requestResult{payload: payload, err: err}
+
+	return true
+}
+
+// closeAll 은 기다리는 모든 request 를 err 로 끝내고, 이후의 request 는 받지 않는다.
+func (p *pendingRequests) closeAll(err error) {
+
+	p.Lock()
+	defer p.Unlock()
+
+	p.closed = true
+
+	for requestID, result := range p.requests {
+		delete(p.requests, requestID)
+		result <- requestResult{err: err}
+	}
+}
@@ -0,0 +1,144 @@
+package bifrost_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/DE-labtory/bifrost/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestConnPair(t *testing.T, opts bifrost.ConnOpts) (bifrost.Connection, bifrost.Connection) {
+	return newTestConnPairWithOpts(t, opts, opts, nil)
+}
+
+// session 이 nil 이 아니면 두 connection 이 같은 session 을 사용한다.
+func newTestConnPairWithOpts(t *testing.T, localOpts bifrost.ConnOpts, remoteOpts bifrost.ConnOpts, session *bifrost.Session) (bifrost.Connection, bifrost.Connection) {
+	localKeyOpts := mocks.NewMockKeyOpts()
+	remoteKeyOpts := mocks.NewMockKeyOpts()
+	local, remote := mocks.NewMockStreamPipe()
+
+	localConn, err := bifrost.NewConnection("127.0.0.1:1234", nil, localKeyOpts.PubKey, remoteKeyOpts.PubKey, local, mocks.NewMockCryptoWithKey(localKeyOpts.PriKey), localOpts, session)
+	assert.NoError(t, err)
+	remoteConn, err := bifrost.NewConnection("127.0.0.1:1235", nil, remoteKeyOpts.PubKey, localKeyOpts.PubKey, remote, mocks.NewMockCryptoWithKey(remoteKeyOpts.PriKey), remoteOpts, session)
+	assert.NoError(t, err)
+
+	return localConn, remoteConn
+}
+
+// newTestConn 은 keyOpts 를 자신과 상대방의 key 로 사용하는 connection 과 상대방 쪽 stream 을 만든다.
+func newTestConn(t *testing.T, keyOpts bifrost.KeyOpts, opts bifrost.ConnOpts, session *bifrost.Session) (bifrost.Connection, *mocks.MockPipeStreamWrapper) {
+	local, remote := mocks.NewMockStreamPipe()
+
+	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, keyOpts.PubKey, local, mocks.NewMockCryptoWithKey(keyOpts.PriKey), opts, session)
+	assert.NoError(t, err)
+
+	return conn, remote
+}
+
+func TestGrpcConnection_Request(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPair(t, bifrost.ConnOpts{})
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		message.Respond(append([]byte("hello "), message.Data...), message.Envelope.Protocol, nil, nil)
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// when
+	response, err := localConn.Request(context.Background(), []byte("jun"), "greeting")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello jun"), response)
+}
+
+func TestGrpcConnection_Request_whenCalledFromHandler(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPair(t, bifrost.ConnOpts{})
+	localConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		message.Respond([]byte("jun"), message.Envelope.Protocol, nil, nil)
+	}})
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		if message.Envelope.Protocol != "greeting" {
+			return
+		}
+
+		// handler 안에서 상대방에게 다시 요청한다.
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		name, err := remoteConn.Request(ctx, nil, "name")
+		if err != nil {
+			message.Respond([]byte(err.Error()), message.Envelope.Protocol, nil, nil)
+			return
+		}
+
+		message.Respond(append([]byte("hello "), name...), message.Envelope.Protocol, nil, nil)
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// when
+	response, err := localConn.Request(ctx, nil, "greeting")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello jun"), response)
+}
+
+func TestGrpcConnection_Request_whenTimeout(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPair(t, bifrost.ConnOpts{})
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// when
+	_, err := localConn.Request(ctx, []byte("jun"), "greeting")
+
+	// then
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestGrpcConnection_Request_whenConnectionClosed(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPair(t, bifrost.ConnOpts{})
+	received := make(chan struct{}, 1)
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		received <- struct{}{}
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := localConn.Request(context.Background(), []byte("jun"), "greeting")
+		done <- err
+	}()
+	<-received
+
+	// when
+	localConn.Close()
+
+	// then
+	assert.Equal(t, bifrost.ErrConnClosed, <-done)
+
+	_, err := localConn.Request(context.Background(), []byte("jun"), "greeting")
+	assert.Equal(t, bifrost.ErrConnClosed, err)
+}
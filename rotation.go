@@ -0,0 +1,73 @@
+package bifrost
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/DE-labtory/bifrost/pb"
+)
+
+var ErrInvalidKeyRotation = errors.New("invalid key rotation")
+
+// proof 서명 대상 인코딩의 prefix. envelope 서명과 같은 byte 열이 만들어지지 않도록 구분한다.
+const rotationVersion = "bifrost-key-rotation-v1"
+
+// KeyRotation 은 ROTATE_KEY envelope 의 payload 이다.
+// envelope 은 이전 key 로 서명하고, Proof 는 새 key 로 서명해서 새 key 의 소유를 증명한다.
+type KeyRotation struct {
+	NewKeyBytes []byte
+	Proof       []byte
+}
+
+// KeyRotationListener 는 상대방의 key 가 바뀐 뒤 호출된다. oldID 는 바뀌기 전의 connection ID 이다.
+type KeyRotationListener func(conn Connection, oldID ConnID)
+
+// peerIdentity 는 key rotation 으로 함께 바뀌는 peer 의 key 정보이다.
+type peerIdentity struct {
+	key      Key
+	keyBytes []byte
+}
+
+// rotationMessage 는 이전 key 와 새 key 를 함께 묶어, proof 를 다른 key 의 rotation 에 재사용할 수 없게 한다.
+func rotationMessage(oldKeyBytes []byte, newKeyBytes []byte) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(rotationVersion)
+
+	writeField(buf, 1, oldKeyBytes)
+	writeField(buf, 2, newKeyBytes)
+
+	return buf.Bytes()
+}
+
+// buildKeyRotation 은 새 key 의 signer 로 proof 를 만들어 ROTATE_KEY payload 를 만든다.
+func buildKeyRotation(oldKeyBytes []byte, newKeyBytes []byte, newSigner Signer) ([]byte, error) {
+
+	proof, err := newSigner.Sign(rotationMessage(oldKeyBytes, newKeyBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(KeyRotation{NewKeyBytes: newKeyBytes, Proof: proof})
+}
+
+// verifyKeyRotation 은 이전 key 로 서명이 확인된 ROTATE_KEY envelope 에서 새 key 를 꺼내고 proof 를 검증한다.
+func verifyKeyRotation(envelope *pb.Envelope, oldKeyBytes []byte, crypto Crypto) (*peerIdentity, error) {
+
+	rotation := KeyRotation{}
+	if err := json.Unmarshal(envelope.Payload, &rotation); err != nil {
+		return nil, ErrInvalidKeyRotation
+	}
+
+	newKey, err := crypto.RecoverKeyFromByte(rotation.NewKeyBytes, false)
+	if err != nil {
+		return nil, ErrInvalidKeyRotation
+	}
+
+	flag, err := crypto.Verify(newKey, rotation.Proof, rotationMessage(oldKeyBytes, rotation.NewKeyBytes))
+	if err != nil || !flag {
+		return nil, ErrInvalidKeyRotation
+	}
+
+	return &peerIdentity{key: newKey, keyBytes: rotation.NewKeyBytes}, nil
+}
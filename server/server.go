@@ -7,16 +7,36 @@ import (
 
 	"encoding/json"
 
-	"time"
-
 	"github.com/DE-labtory/bifrost"
 	"github.com/DE-labtory/bifrost/pb"
 	"github.com/DE-labtory/iLogger"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/reflection"
 )
 
+// Authorizer 는 handshake 를 마친 peer 의 연결을 허용할지 결정한다.
+// 거절할 경우 거절 사유를 담은 error 를 반환하며, 사유는 연결을 요청한 client 에게 전달된다.
+type Authorizer interface {
+	Authorize(peerKey bifrost.Key, peerInfo bifrost.PeerInfo, remoteAddress string) error
+}
+
+// AuthorizerFunc 는 함수를 Authorizer 로 사용할 수 있게 한다.
+type AuthorizerFunc func(peerKey bifrost.Key, peerInfo bifrost.PeerInfo, remoteAddress string) error
+
+func (f AuthorizerFunc) Authorize(peerKey bifrost.Key, peerInfo bifrost.PeerInfo, remoteAddress string) error {
+	return f(peerKey, peerInfo, remoteAddress)
+}
+
+// Client 와 연결시 사용되는 grpc option.
+type GrpcOpts struct {
+	TlsEnabled bool
+	Creds      credentials.TransportCredentials
+	// client 의 TLS 인증서 public key 가 handshake 에서 받은 client 의 key 와 같은지 확인한다.
+	VerifyPeerCertKey bool
+}
+
 type Server struct {
 	onConnectionHandler OnConnectionHandler
 	onErrorHandler      OnErrorHandler
@@ -24,6 +44,10 @@ type Server struct {
 	ip                  string
 	lis                 net.Listener
 	metaData            map[string]string
+	connOpts            bifrost.ConnOpts
+	authorizer          Authorizer
+	grpcOpts            GrpcOpts
+	handshakeOpts       bifrost.HandshakeOpts
 	bifrost.Crypto
 }
 
@@ -34,16 +58,25 @@ func (s Server) BifrostStream(streamServer pb.StreamService_BifrostStreamServer)
 
 	ip := extractRemoteAddress(streamServer)
 
+	// 차단된 IP 는 handshake 를 시작하지 않는다.
+	if err := s.connOpts.Reputation.CheckPeer("", ip); err != nil {
+		iLogger.Infof(nil, "[Bifrost] Refuse banned peer [%s]", ip)
+		return err
+	}
+
 	_, cf := context.WithCancel(context.Background())
 	streamWrapper := bifrost.NewServerStreamWrapper(streamServer, cf)
 
-	peerKey, metaData, err := s.handShake(streamWrapper)
+	result, err := s.handShake(streamServer.Context(), streamWrapper, ip)
 
 	if err != nil {
+		if s.connOpts.Reputation != nil && !errors.Is(err, bifrost.ErrPeerRejected) {
+			s.connOpts.Reputation.Report("", ip, bifrost.EventHandshakeFailure)
+		}
 		return err
 	}
 
-	conn, err := bifrost.NewConnection(ip, metaData, peerKey, streamWrapper, s.Crypto)
+	conn, err := bifrost.NewConnection(ip, result.PeerInfo.MetaData, s.pubKey, result.PeerKey, streamWrapper, s.Crypto, s.connOpts, result.Session)
 
 	if s.onConnectionHandler != nil {
 		s.onConnectionHandler(conn)
@@ -52,85 +85,52 @@ func (s Server) BifrostStream(streamServer pb.StreamService_BifrostStreamServer)
 	return nil
 }
 
-func (s Server) handShake(streamWrapper bifrost.StreamWrapper) (bifrost.Key, map[string]string, error) {
+// handshake 함수. 실패하면 stream 은 닫힌다.
+func (s Server) handShake(ctx context.Context, streamWrapper bifrost.StreamWrapper, remoteAddress string) (*bifrost.HandshakeResult, error) {
 
-	err := requestInfo(streamWrapper)
+	peerInfo, err := bifrost.NewPeerInfo(s.ip, s.pubKey, s.metaData)
 
 	if err != nil {
 		streamWrapper.Close()
-		return nil, nil, err
+		return nil, errors.New("fail to build info")
 	}
 
-	peerKey, metaData, err := s.getClientInfo(streamWrapper)
-
-	if err != nil {
-		streamWrapper.Close()
-		return nil, nil, err
-	}
+	handshaker := bifrost.NewServerHandshaker(streamWrapper, s.Crypto, peerInfo, s.connOpts, s.handshakeOpts)
+	handshaker.OnPeerInfo(func(peerKey bifrost.Key, peerInfo *bifrost.PeerInfo) error {
+		return s.verifyPeer(streamWrapper, peerKey, *peerInfo, remoteAddress)
+	})
 
-	err = s.sendInfo(streamWrapper, metaData)
+	result, err := handshaker.Run(ctx)
 
 	if err != nil {
-		streamWrapper.Close()
-		return nil, nil, err
+		iLogger.Infof(nil, "[Bifrost] Handshake failed [%s]", err.Error())
+		return nil, err
 	}
 
 	iLogger.Info(nil, "[Bifrost] Handshake success")
 
-	return peerKey, metaData, nil
-}
-
-func requestInfo(streamWrapper bifrost.StreamWrapper) error {
-	envelope := &pb.Envelope{Type: pb.Envelope_REQUEST_PEERINFO}
-
-	if err := streamWrapper.Send(envelope); err != nil {
-		return err
-	}
-
-	return nil
+	return result, nil
 }
 
-func (s Server) sendInfo(streamWrapper bifrost.StreamWrapper, metaData map[string]string) error {
-
-	envelope, err := bifrost.BuildResponsePeerInfo(s.ip, s.pubKey, metaData)
-
-	if err != nil {
-		return errors.New("fail to build info")
-	}
+// verifyPeer 는 서명을 검증한 client 의 연결을 허용할지 확인한다. error 를 반환하면 거절 사유로 client 에게 전달된다.
+// 차단된 peer 이거나, TLS 인증서의 key 가 client 의 key 와 다르거나, authorizer 가 거절하면 연결을 거절한다.
+func (s Server) verifyPeer(streamWrapper bifrost.StreamWrapper, peerKey bifrost.Key, peerInfo bifrost.PeerInfo, remoteAddress string) error {
 
-	if err = streamWrapper.Send(envelope); err != nil {
+	if err := s.connOpts.Reputation.CheckPeer(peerKey.ID(), remoteAddress); err != nil {
 		return err
 	}
 
-	return nil
-}
-
-func (s Server) getClientInfo(streamWrapper bifrost.StreamWrapper) (bifrost.Key, map[string]string, error) {
-
-	env, err := bifrost.RecvWithTimeout(3*time.Second, streamWrapper)
-
-	if err != nil {
-		return nil, nil, err
-	}
-
-	if env.GetType() != pb.Envelope_RESPONSE_PEERINFO {
-		iLogger.Info(nil, "[Bifrost] Invaild message type")
-		return nil, nil, errors.New("invalid message type")
-	}
-
-	peerInfo := &bifrost.PeerInfo{}
-
-	err = json.Unmarshal(env.Payload, peerInfo)
-	if err != nil {
-		return nil, nil, err
+	if s.grpcOpts.TlsEnabled && s.grpcOpts.VerifyPeerCertKey {
+		if err := bifrost.VerifyPeerCertificateKey(streamWrapper.Context(), peerKey); err != nil {
+			return err
+		}
 	}
 
-	pubKey, err := s.Crypto.RecoverKeyFromByte(peerInfo.PubKeyBytes, peerInfo.IsPrivate)
-	if err != nil {
-		return nil, nil, err
+	if s.authorizer == nil {
+		return nil
 	}
 
-	return pubKey, peerInfo.MetaData, nil
+	return s.authorizer.Authorize(peerKey, peerInfo, remoteAddress)
 }
 
 func (s Server) validateRequestPeerInfo(envelope *pb.Envelope) (bool, string, bifrost.Key) {
@@ -200,6 +200,26 @@ func (s *Server) OnConnection(handler OnConnectionHandler) {
 	s.onConnectionHandler = handler
 }
 
+// SetAuthorizer 는 handshake 를 마친 peer 의 연결 허용 여부를 결정할 Authorizer 를 지정한다.
+func (s *Server) SetAuthorizer(authorizer Authorizer) {
+	s.authorizer = authorizer
+}
+
+// SetGrpcOpts 는 Listen 에서 사용할 grpc option 을 지정한다. TLS 를 사용하려면 Listen 전에 지정해야 한다.
+func (s *Server) SetGrpcOpts(opts GrpcOpts) {
+	s.grpcOpts = opts
+}
+
+// SetConnOpts 는 server 가 받아들이는 connection 에 사용할 option 을 지정한다.
+func (s *Server) SetConnOpts(opts bifrost.ConnOpts) {
+	s.connOpts = opts
+}
+
+// SetHandshakeOpts 는 handshake 단계별 제한 시간을 지정한다.
+func (s *Server) SetHandshakeOpts(opts bifrost.HandshakeOpts) {
+	s.handshakeOpts = opts
+}
+
 func (s *Server) OnError(handler OnErrorHandler) {
 
 	if handler == nil {
@@ -217,7 +237,13 @@ func (s *Server) Listen(ip string) {
 	}
 	defer lis.Close()
 
-	g := grpc.NewServer()
+	var opts []grpc.ServerOption
+
+	if s.grpcOpts.TlsEnabled {
+		opts = append(opts, grpc.Creds(s.grpcOpts.Creds))
+	}
+
+	g := grpc.NewServer(opts...)
 
 	defer g.Stop()
 	pb.RegisterStreamServiceServer(g, s)
@@ -2,12 +2,14 @@ package server_test
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/DE-labtory/bifrost"
 	"github.com/DE-labtory/bifrost/mocks"
 	"github.com/DE-labtory/bifrost/pb"
+	"github.com/DE-labtory/bifrost/server"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -52,9 +54,10 @@ func TestServer_BifrostStream(t *testing.T) {
 		IP:          "127.0.0.1",
 		PubKeyBytes: keyBytes,
 		IsPrivate:   keyOpt.PubKey.IsPrivate(),
+		Version:     bifrost.ProtocolVersion,
 	}
 
-	mockStreamServer := mocks.NewMockStreamServer(*peerInfo)
+	mockStreamServer := mocks.NewMockStreamServer(*peerInfo, mocks.NewMockCryptoWithKey(keyOpt.PriKey).Signer)
 
 	// when
 	err = s.BifrostStream(mockStreamServer)
@@ -63,6 +66,144 @@ func TestServer_BifrostStream(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestServer_BifrostStream_whenInvalidPeerSignature(t *testing.T) {
+	// given
+	s := mocks.NewMockServer()
+
+	keyOpt := mocks.NewMockKeyOpts()
+	keyBytes, err := keyOpt.PubKey.ToByte()
+	assert.NoError(t, err)
+
+	peerInfo := &bifrost.PeerInfo{
+		IP:          "127.0.0.1",
+		PubKeyBytes: keyBytes,
+		IsPrivate:   keyOpt.PubKey.IsPrivate(),
+		Version:     bifrost.ProtocolVersion,
+	}
+
+	// peer info 의 key 와 다른 key 로 서명
+	otherKeyOpt := mocks.NewMockKeyOpts()
+	mockStreamServer := mocks.NewMockStreamServer(*peerInfo, mocks.NewMockCryptoWithKey(otherKeyOpt.PriKey).Signer)
+
+	// when
+	err = s.BifrostStream(mockStreamServer)
+
+	// then
+	assert.Equal(t, bifrost.ErrInvalidPeerSignature, err)
+}
+
+func TestServer_BifrostStream_whenPeerRejected(t *testing.T) {
+	// given
+	s := mocks.NewMockServer()
+
+	keyOpt := mocks.NewMockKeyOpts()
+	keyBytes, err := keyOpt.PubKey.ToByte()
+	assert.NoError(t, err)
+
+	peerInfo := &bifrost.PeerInfo{
+		IP:          "127.0.0.1",
+		PubKeyBytes: keyBytes,
+		IsPrivate:   keyOpt.PubKey.IsPrivate(),
+		Version:     bifrost.ProtocolVersion,
+	}
+
+	var authorizedKey bifrost.Key
+	var authorizedAddress string
+	s.SetAuthorizer(server.AuthorizerFunc(func(peerKey bifrost.Key, peerInfo bifrost.PeerInfo, remoteAddress string) error {
+		authorizedKey = peerKey
+		authorizedAddress = remoteAddress
+		return errors.New("not in allowlist")
+	}))
+
+	mockStreamServer := mocks.NewMockStreamServer(*peerInfo, mocks.NewMockCryptoWithKey(keyOpt.PriKey).Signer)
+
+	// when
+	err = s.BifrostStream(mockStreamServer)
+
+	// then
+	assert.True(t, errors.Is(err, bifrost.ErrPeerRejected))
+	assert.Equal(t, keyOpt.PubKey.ID(), authorizedKey.ID())
+	assert.Equal(t, "127.0.0.1:7777", authorizedAddress)
+
+	rejectEnvelope := mockStreamServer.Sent[len(mockStreamServer.Sent)-1]
+	assert.Equal(t, pb.Envelope_REJECT_PEER, rejectEnvelope.Type)
+	assert.Equal(t, []byte("not in allowlist"), rejectEnvelope.Payload)
+}
+
+func TestServer_BifrostStream_whenPeerBanned(t *testing.T) {
+	// given
+	s := mocks.NewMockServer()
+
+	keyOpt := mocks.NewMockKeyOpts()
+	keyBytes, err := keyOpt.PubKey.ToByte()
+	assert.NoError(t, err)
+
+	peerInfo := &bifrost.PeerInfo{
+		IP:          "127.0.0.1",
+		PubKeyBytes: keyBytes,
+		IsPrivate:   keyOpt.PubKey.IsPrivate(),
+		Version:     bifrost.ProtocolVersion,
+	}
+
+	reputation := bifrost.NewReputation(bifrost.ReputationOpts{})
+	reputation.Ban(keyOpt.PubKey.ID(), "", time.Hour)
+	s.SetConnOpts(bifrost.ConnOpts{Reputation: reputation})
+
+	mockStreamServer := mocks.NewMockStreamServer(*peerInfo, mocks.NewMockCryptoWithKey(keyOpt.PriKey).Signer)
+
+	// when
+	err = s.BifrostStream(mockStreamServer)
+
+	// then
+	assert.Equal(t, &bifrost.PeerRejectedError{Reason: bifrost.ErrPeerBanned.Error()}, err)
+}
+
+func TestServer_BifrostStream_whenIPBanned(t *testing.T) {
+	// given
+	s := mocks.NewMockServer()
+
+	reputation := bifrost.NewReputation(bifrost.ReputationOpts{})
+	reputation.Ban("", "127.0.0.1:7777", time.Hour)
+	s.SetConnOpts(bifrost.ConnOpts{Reputation: reputation})
+
+	mockStreamServer := mocks.NewMockStreamServer(bifrost.PeerInfo{}, mocks.NewMockCrypto().Signer)
+
+	// when
+	err := s.BifrostStream(mockStreamServer)
+
+	// then
+	assert.Equal(t, bifrost.ErrPeerBanned, err)
+	assert.Empty(t, mockStreamServer.Sent)
+}
+
+func TestServer_BifrostStream_whenIncompatibleVersion(t *testing.T) {
+	// given
+	s := mocks.NewMockServer()
+
+	keyOpt := mocks.NewMockKeyOpts()
+	keyBytes, err := keyOpt.PubKey.ToByte()
+	assert.NoError(t, err)
+
+	// version 을 알리지 않는 peer
+	peerInfo := &bifrost.PeerInfo{
+		IP:          "127.0.0.1",
+		PubKeyBytes: keyBytes,
+		IsPrivate:   keyOpt.PubKey.IsPrivate(),
+	}
+
+	mockStreamServer := mocks.NewMockStreamServer(*peerInfo, mocks.NewMockCryptoWithKey(keyOpt.PriKey).Signer)
+
+	// when
+	err = s.BifrostStream(mockStreamServer)
+
+	// then
+	assert.True(t, errors.Is(err, bifrost.ErrPeerRejected))
+
+	rejectEnvelope := mockStreamServer.Sent[len(mockStreamServer.Sent)-1]
+	assert.Equal(t, pb.Envelope_REJECT_PEER, rejectEnvelope.Type)
+	assert.Contains(t, string(rejectEnvelope.Payload), bifrost.ErrIncompatibleVersion.Error())
+}
+
 func TestServer_Listen(t *testing.T) {
 	// given
 	s := mocks.NewMockServer()
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// NewTLSCreds 는 server 인증서로 TLS credentials 를 만든다.
+func NewTLSCreds(cert tls.Certificate) credentials.TransportCredentials {
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+	})
+}
+
+// NewMutualTLSCreds 는 clientCAs 로 검증되는 client 인증서를 요구하는 mutual TLS credentials 를 만든다.
+func NewMutualTLSCreds(cert tls.Certificate, clientCAs *x509.CertPool) credentials.TransportCredentials {
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+}
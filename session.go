@@ -0,0 +1,130 @@
+package bifrost
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/DE-labtory/bifrost/pb"
+)
+
+// 자신은 암호화를 사용하도록 설정했지만 상대방이 암호화를 지원하지 않는 경우 발생하는 에러
+var ErrEncryptionNotSupported = errors.New("peer does not support encryption")
+
+var ErrDecryptionFailed = errors.New("fail to decrypt envelope")
+
+var (
+	clientToServerLabel = []byte("bifrost client to server")
+	serverToClientLabel = []byte("bifrost server to client")
+)
+
+// Session 은 handshake 에서 상대방과 합의한 연결 상태이다.
+//
+// 암호화를 사용하는 경우 ephemeral ECDH 로 합의한 key 로 envelope 의 payload 를 암호화한다.
+// 방향마다 다른 key 를 사용하고 envelope 의 sequence number 를 nonce 로 사용하므로 nonce 가 재사용되지 않는다.
+type Session struct {
+	// 합의한 wire protocol version
+	Version uint32
+	// 양쪽 모두 지원하는 기능
+	Features FeatureSet
+	// 상대방이 처리하는 mux protocol 목록
+	PeerProtocols []string
+	sendCipher    cipher.AEAD
+	recvCipher    cipher.AEAD
+}
+
+// NewEphemeralKey 는 handshake 한 번에만 사용할 ECDH key 를 생성한다.
+func NewEphemeralKey() (*ecdh.PrivateKey, error) {
+	return ecdh.X25519().GenerateKey(rand.Reader)
+}
+
+// EstablishKeys 는 자신의 ephemeral key 와 상대방의 ephemeral public key 로 방향별 AEAD key 를 만든다.
+// 두 nonce 는 handshake 에서 server 와 client 가 보낸 challenge nonce 이며, 연결마다 다른 key 를 만들기 위해 사용한다.
+func (s *Session) EstablishKeys(ephemeralKey *ecdh.PrivateKey, peerEphemeralKey []byte, serverNonce []byte, clientNonce []byte, isClient bool) error {
+
+	peerKey, err := ecdh.X25519().NewPublicKey(peerEphemeralKey)
+	if err != nil {
+		return err
+	}
+
+	shared, err := ephemeralKey.ECDH(peerKey)
+	if err != nil {
+		return err
+	}
+
+	salt := challengeMessage(serverNonce, clientNonce)
+	secret := hmacSHA256(salt, shared)
+
+	clientToServer, err := newAEAD(hmacSHA256(secret, clientToServerLabel))
+	if err != nil {
+		return err
+	}
+
+	serverToClient, err := newAEAD(hmacSHA256(secret, serverToClientLabel))
+	if err != nil {
+		return err
+	}
+
+	if isClient {
+		s.sendCipher, s.recvCipher = clientToServer, serverToClient
+	} else {
+		s.sendCipher, s.recvCipher = serverToClient, clientToServer
+	}
+
+	return nil
+}
+
+// Encrypted 는 payload 를 암호화하는 session 인지 확인한다.
+func (s *Session) Encrypted() bool {
+	return s != nil && s.sendCipher != nil
+}
+
+// seal 은 서명이 끝난 envelope 의 payload 를 암호화한다.
+func (s *Session) seal(envelope *pb.Envelope) {
+	envelope.Payload = s.sendCipher.Seal(nil, aeadNonce(envelope.Lane, envelope.Seq), envelope.Payload, nil)
+	envelope.Encrypted = true
+}
+
+// open 은 envelope 의 payload 를 복호화한다. 서명은 복호화한 payload 로 검증해야 한다.
+func (s *Session) open(envelope *pb.Envelope) error {
+
+	payload, err := s.recvCipher.Open(nil, aeadNonce(envelope.Lane, envelope.Seq), envelope.Payload, nil)
+	if err != nil {
+		return ErrDecryptionFailed
+	}
+
+	envelope.Payload = payload
+	envelope.Encrypted = false
+
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// lane 마다 sequence number 가 따로 증가하므로 nonce 에 lane 을 포함해야 같은 nonce 를 다시 사용하지 않는다.
+func aeadNonce(lane uint32, seq uint64) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint32(nonce[:4], lane)
+	binary.BigEndian.PutUint64(nonce[4:], seq)
+
+	return nonce
+}
+
+func hmacSHA256(key []byte, message []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+
+	return mac.Sum(nil)
+}
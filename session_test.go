@@ -0,0 +1,69 @@
+package bifrost
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/bifrost/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSessions(t *testing.T) (*Session, *Session) {
+	serverNonce, err := NewNonce()
+	assert.NoError(t, err)
+	clientNonce, err := NewNonce()
+	assert.NoError(t, err)
+
+	clientKey, err := NewEphemeralKey()
+	assert.NoError(t, err)
+	serverKey, err := NewEphemeralKey()
+	assert.NoError(t, err)
+
+	clientSession := &Session{}
+	err = clientSession.EstablishKeys(clientKey, serverKey.PublicKey().Bytes(), serverNonce, clientNonce, true)
+	assert.NoError(t, err)
+	serverSession := &Session{}
+	err = serverSession.EstablishKeys(serverKey, clientKey.PublicKey().Bytes(), serverNonce, clientNonce, false)
+	assert.NoError(t, err)
+
+	return clientSession, serverSession
+}
+
+func TestSession_sealAndOpen(t *testing.T) {
+	// given
+	clientSession, serverSession := newTestSessions(t)
+	envelope := &pb.Envelope{Payload: []byte("hello"), Seq: 1}
+
+	// when
+	clientSession.seal(envelope)
+	assert.True(t, envelope.Encrypted)
+	assert.NotEqual(t, []byte("hello"), envelope.Payload)
+
+	err := serverSession.open(envelope)
+
+	// then
+	assert.NoError(t, err)
+	assert.False(t, envelope.Encrypted)
+	assert.Equal(t, []byte("hello"), envelope.Payload)
+}
+
+func TestSession_open_whenTampered(t *testing.T) {
+	// given
+	clientSession, serverSession := newTestSessions(t)
+
+	tamperedPayload := &pb.Envelope{Payload: []byte("hello"), Seq: 1}
+	clientSession.seal(tamperedPayload)
+	tamperedPayload.Payload[0] ^= 0xff
+
+	tamperedSeq := &pb.Envelope{Payload: []byte("hello"), Seq: 1}
+	clientSession.seal(tamperedSeq)
+	tamperedSeq.Seq = 2
+
+	// 같은 방향의 key 로는 열 수 없다.
+	sameDirection := &pb.Envelope{Payload: []byte("hello"), Seq: 1}
+	clientSession.seal(sameDirection)
+
+	// when, then
+	assert.Equal(t, ErrDecryptionFailed, serverSession.open(tamperedPayload))
+	assert.Equal(t, ErrDecryptionFailed, serverSession.open(tamperedSeq))
+	assert.Equal(t, ErrDecryptionFailed, clientSession.open(sameDirection))
+}
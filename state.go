@@ -0,0 +1,93 @@
+package bifrost
+
+import (
+	"sync/atomic"
+)
+
+// ConnState 는 connection 의 생명주기 상태이다.
+type ConnState int32
+
+const (
+	// handshake 를 마치고 만들어졌지만 Start 로 메세지를 주고받기 전.
+	// handshake 는 connection 을 만들기 전에 하므로 handshake 중인 connection 은 없다.
+	ConnIdle ConnState = iota
+	// Start 로 메세지를 주고받는 중
+	ConnActive
+	// CloseGracefully 로 남은 메세지를 보내는 중. 새 메세지는 보낼 수 없다.
+	ConnDraining
+	// 연결이 끊긴 상태. 다른 상태로 바뀌지 않는다.
+	ConnClosed
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case ConnIdle:
+		return "idle"
+	case ConnActive:
+		return "active"
+	case ConnDraining:
+		return "draining"
+	case ConnClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// StateChangeListener 는 connection 의 상태가 바뀐 뒤 호출된다.
+type StateChangeListener func(conn Connection, state ConnState)
+
+func (conn *GrpcConnection) State() ConnState {
+	return ConnState(atomic.LoadInt32(&conn.state))
+}
+
+// Done 은 연결이 끊기면 닫히는 channel 을 반환한다.
+func (conn *GrpcConnection) Done() <-chan struct{} {
+	return conn.closed
+}
+
+// Err 는 연결이 끊긴 원인을 반환한다. 연결이 끊기기 전에는 nil 이며,
+// 직접 Close 하거나 CloseGracefully 로 끊은 경우 ErrConnClosed 이다.
+func (conn *GrpcConnection) Err() error {
+
+	select {
+	case <-conn.closed:
+		return conn.err
+	default:
+		return nil
+	}
+}
+
+// OnStateChange 는 상태가 바뀐 뒤 호출할 listener 를 등록한다.
+func (conn *GrpcConnection) OnStateChange(listener StateChangeListener) {
+
+	conn.stateLock.Lock()
+	defer conn.stateLock.Unlock()
+
+	conn.stateListeners = append(conn.stateListeners, listener)
+}
+
+// setState 는 상태를 바꾸고 listener 에게 알린다.
+// 상태는 idle, active, draining, closed 순서로만 바뀌며 이전 상태로 돌아가지 않는다.
+func (conn *GrpcConnection) setState(state ConnState) {
+
+	for {
+		old := atomic.LoadInt32(&conn.state)
+		if ConnState(old) >= state {
+			return
+		}
+
+		if atomic.CompareAndSwapInt32(&conn.state, old, int32(state)) {
+			break
+		}
+	}
+
+	conn.stateLock.Lock()
+	listeners := make([]StateChangeListener, len(conn.stateListeners))
+	copy(listeners, conn.stateListeners)
+	conn.stateLock.Unlock()
+
+	for _, listener := range listeners {
+		listener(conn, state)
+	}
+}
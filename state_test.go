@@ -0,0 +1,73 @@
+package bifrost_test
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/DE-labtory/bifrost/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrpcConnection_OnStateChange(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPair(t, bifrost.ConnOpts{})
+
+	var lock sync.Mutex
+	var states []bifrost.ConnState
+	active := make(chan struct{})
+	localConn.OnStateChange(func(conn bifrost.Connection, state bifrost.ConnState) {
+		lock.Lock()
+		defer lock.Unlock()
+		states = append(states, state)
+
+		if state == bifrost.ConnActive {
+			close(active)
+		}
+	})
+
+	assert.Equal(t, bifrost.ConnIdle, localConn.State())
+
+	go localConn.Start()
+	go remoteConn.Start()
+	<-active
+
+	// when
+	err := localConn.CloseGracefully(context.Background(), bifrost.CloseReason{Code: bifrost.CloseNormal, Message: "bye"})
+
+	// then
+	assert.NoError(t, err)
+	<-localConn.Done()
+	<-remoteConn.Done()
+
+	lock.Lock()
+	assert.Equal(t, []bifrost.ConnState{bifrost.ConnActive, bifrost.ConnDraining, bifrost.ConnClosed}, states)
+	lock.Unlock()
+
+	assert.Equal(t, bifrost.ConnClosed, localConn.State())
+	assert.Equal(t, bifrost.ErrConnClosed, localConn.Err())
+	assert.Equal(t, &bifrost.GoAwayError{Reason: bifrost.CloseReason{Code: bifrost.CloseNormal, Message: "bye"}}, remoteConn.Err())
+}
+
+func TestGrpcConnection_Err_whenStreamBroken(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	conn, remote := newTestConn(t, keyOpts, bifrost.ConnOpts{}, nil)
+	assert.NoError(t, conn.Err())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- conn.Start()
+	}()
+
+	// when
+	remote.Close()
+
+	// then
+	assert.Equal(t, io.EOF, <-done)
+	<-conn.Done()
+	assert.Equal(t, io.EOF, conn.Err())
+	assert.Equal(t, bifrost.ConnClosed, conn.State())
+}
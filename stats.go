@@ -0,0 +1,139 @@
+package bifrost
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ProtocolStats 는 protocol 별로 주고받은 메세지 수와 payload byte 수이다.
+// byte 수는 압축하거나 암호화한 뒤 실제로 전송된 payload 의 크기이다.
+type ProtocolStats struct {
+	MessagesSent     uint64
+	BytesSent        uint64
+	MessagesReceived uint64
+	BytesReceived    uint64
+}
+
+// ConnStats 는 connection 의 통계이다. PING, ROTATE_KEY 같은 제어 메세지는 빈 protocol 로 집계된다.
+type ConnStats struct {
+	Protocols map[string]ProtocolStats
+	// 전송을 기다리는 메세지 수
+	QueueDepth int
+	// stream 에 쓰지 못한 메세지 수
+	SendFailures uint64
+	// 복호화나 서명 검증에 실패한 메세지 수
+	VerifyFailures uint64
+	// 재전송, 응답을 기다리지 않는 response 등으로 버린 메세지와 연결이 끊겨 보내지 못한 메세지 수
+	DroppedMessages uint64
+	// 연결을 만든 뒤 지난 시간
+	Age time.Duration
+	// 마지막으로 메세지를 주고받은 시간. 주고받은 적이 없으면 zero value 이다.
+	LastActivity time.Time
+}
+
+type protocolCounters struct {
+	messagesSent     uint64
+	bytesSent        uint64
+	messagesReceived uint64
+	bytesReceived    uint64
+}
+
+// connStats 는 전송 경로에서 lock 없이 갱신할 수 있도록 atomic counter 로 통계를 모은다.
+// protocol 별 counter 는 sync.Map 에 처음 볼 때 만든다.
+type connStats struct {
+	protocols      sync.Map
+	sendFailures   uint64
+	verifyFailures uint64
+	dropped        uint64
+	createdAt      time.Time
+	lastActivity   int64
+}
+
+func newConnStats() *connStats {
+	return &connStats{
+		createdAt: time.Now(),
+	}
+}
+
+func (s *connStats) protocol(protocol string) *protocolCounters {
+
+	if counters, ok := s.protocols.Load(protocol); ok {
+		return counters.(*protocolCounters)
+	}
+
+	counters, _ := s.protocols.LoadOrStore(protocol, &protocolCounters{})
+
+	return counters.(*protocolCounters)
+}
+
+func (s *connStats) sent(protocol string, size int) {
+
+	counters := s.protocol(protocol)
+	atomic.AddUint64(&counters.messagesSent, 1)
+	atomic.AddUint64(&counters.bytesSent, uint64(size))
+
+	s.touch()
+}
+
+func (s *connStats) received(protocol string, size int) {
+
+	counters := s.protocol(protocol)
+	atomic.AddUint64(&counters.messagesReceived, 1)
+	atomic.AddUint64(&counters.bytesReceived, uint64(size))
+
+	s.touch()
+}
+
+func (s *connStats) sendFailed() {
+	atomic.AddUint64(&s.sendFailures, 1)
+}
+
+func (s *connStats) verifyFailed() {
+	atomic.AddUint64(&s.verifyFailures, 1)
+}
+
+func (s *connStats) drop(n int) {
+	atomic.AddUint64(&s.dropped, uint64(n))
+}
+
+func (s *connStats) touch() {
+	atomic.StoreInt64(&s.lastActivity, time.Now().UnixNano())
+}
+
+func (s *connStats) snapshot() ConnStats {
+
+	stats := ConnStats{
+		Protocols:       make(map[string]ProtocolStats),
+		SendFailures:    atomic.LoadUint64(&s.sendFailures),
+		VerifyFailures:  atomic.LoadUint64(&s.verifyFailures),
+		DroppedMessages: atomic.LoadUint64(&s.dropped),
+		Age:             time.Since(s.createdAt),
+	}
+
+	if lastActivity := atomic.LoadInt64(&s.lastActivity); lastActivity != 0 {
+		stats.LastActivity = time.Unix(0, lastActivity)
+	}
+
+	s.protocols.Range(func(key, value interface{}) bool {
+		counters := value.(*protocolCounters)
+		stats.Protocols[key.(string)] = ProtocolStats{
+			MessagesSent:     atomic.LoadUint64(&counters.messagesSent),
+			BytesSent:        atomic.LoadUint64(&counters.bytesSent),
+			MessagesReceived: atomic.LoadUint64(&counters.messagesReceived),
+			BytesReceived:    atomic.LoadUint64(&counters.bytesReceived),
+		}
+		return true
+	})
+
+	return stats
+}
+
+// Stats 는 현재까지의 통계를 반환한다.
+func (conn *GrpcConnection) Stats() ConnStats {
+
+	stats := conn.stats.snapshot()
+	stats.QueueDepth = conn.outbound.len()
+
+	return stats
+}
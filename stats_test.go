@@ -0,0 +1,94 @@
+package bifrost_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/DE-labtory/bifrost/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrpcConnection_Stats(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPair(t, bifrost.ConnOpts{})
+
+	received := make(chan struct{}, 3)
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		received <- struct{}{}
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	before := time.Now()
+
+	// when
+	assert.NoError(t, localConn.SendSync([]byte("block"), "block"))
+	assert.NoError(t, localConn.SendSync([]byte("block"), "block"))
+	assert.NoError(t, localConn.SendSync([]byte("vote"), "vote"))
+
+	for i := 0; i < 3; i++ {
+		<-received
+	}
+
+	// then
+	sent := localConn.Stats()
+	assert.Equal(t, bifrost.ProtocolStats{MessagesSent: 2, BytesSent: 10}, sent.Protocols["block"])
+	assert.Equal(t, bifrost.ProtocolStats{MessagesSent: 1, BytesSent: 4}, sent.Protocols["vote"])
+	assert.Equal(t, 0, sent.QueueDepth)
+	assert.False(t, sent.LastActivity.Before(before))
+	assert.True(t, sent.Age > 0)
+
+	got := remoteConn.Stats()
+	assert.Equal(t, bifrost.ProtocolStats{MessagesReceived: 2, BytesReceived: 10}, got.Protocols["block"])
+	assert.Equal(t, bifrost.ProtocolStats{MessagesReceived: 1, BytesReceived: 4}, got.Protocols["vote"])
+}
+
+func TestGrpcConnection_Stats_whenVerifyFailed(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	otherKeyOpts := mocks.NewMockKeyOpts()
+	local, remote := mocks.NewMockStreamPipe()
+
+	conn, err := bifrost.NewConnection("127.0.0.1:1234", nil, keyOpts.PubKey, keyOpts.PubKey, local, mocks.NewMockCrypto(), bifrost.ConnOpts{}, nil)
+	assert.NoError(t, err)
+
+	errs := make(chan error, 2)
+	conn.Handle(mocks.MockFuncHandler{ErrorFunc: func(conn bifrost.Connection, err error) {
+		errs <- err
+	}})
+
+	go conn.Start()
+
+	// when
+	assert.NoError(t, remote.Send(newSignedEnvelope(t, otherKeyOpts, 1)))
+	<-errs
+	conn.Close()
+
+	// then
+	stats := conn.Stats()
+	assert.Equal(t, uint64(1), stats.VerifyFailures)
+	assert.Empty(t, stats.Protocols)
+	assert.True(t, stats.LastActivity.IsZero())
+}
+
+func TestGrpcConnection_Stats_whenQueuedMessagesDropped(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	conn, _ := newTestConn(t, keyOpts, bifrost.ConnOpts{}, nil)
+
+	// writeStream 이 동작하지 않으므로 대기열에 남는다.
+	conn.Send([]byte("hello"), "test", nil, nil)
+	conn.Send([]byte("hello"), "test", nil, nil)
+	assert.Equal(t, 2, conn.Stats().QueueDepth)
+
+	// when
+	conn.Close()
+
+	// then
+	stats := conn.Stats()
+	assert.Equal(t, 0, stats.QueueDepth)
+	assert.Equal(t, uint64(2), stats.DroppedMessages)
+}
@@ -13,6 +13,9 @@ type ConnectionID string
 type ConnectionStore struct {
 	sync.RWMutex
 	connMap map[ConnID]Connection
+	// store 의 모든 connection 이 공유하는 limiter
+	sendLimiter    *RateLimiter
+	receiveLimiter *RateLimiter
 }
 
 func NewConnectionStore() *ConnectionStore {
@@ -21,7 +24,7 @@ func NewConnectionStore() *ConnectionStore {
 	}
 }
 
-func (connStore ConnectionStore) AddConnection(conn Connection) error {
+func (connStore *ConnectionStore) AddConnection(conn Connection) error {
 	connStore.Lock()
 	defer connStore.Unlock()
 
@@ -35,27 +38,76 @@ func (connStore ConnectionStore) AddConnection(conn Connection) error {
 	}
 
 	connStore.connMap[connID] = conn
+	conn.SetGlobalRateLimiters(connStore.sendLimiter, connStore.receiveLimiter)
+	conn.OnKeyRotation(connStore.updateConnectionID)
+	conn.OnStateChange(connStore.removeClosedConnection)
 
 	return nil
 }
 
-func (connStore ConnectionStore) DeleteConnection(connID ConnID) error {
+// SetRateLimit 은 store 의 모든 connection 을 합친 보내기, 받기 제한을 지정한다.
+// 이미 추가된 connection 과 이후에 추가되는 connection 모두에 적용된다.
+func (connStore *ConnectionStore) SetRateLimit(send RateLimit, receive RateLimit) {
 	connStore.Lock()
 	defer connStore.Unlock()
 
+	connStore.sendLimiter = newOptionalRateLimiter(send)
+	connStore.receiveLimiter = newOptionalRateLimiter(receive)
+
+	for _, conn := range connStore.connMap {
+		conn.SetGlobalRateLimiters(connStore.sendLimiter, connStore.receiveLimiter)
+	}
+}
+
+// removeClosedConnection 은 끊긴 connection 을 store 에서 삭제한다.
+func (connStore *ConnectionStore) removeClosedConnection(conn Connection, state ConnState) {
+
+	if state != ConnClosed {
+		return
+	}
+
+	connStore.Lock()
+	defer connStore.Unlock()
+
+	if stored, ok := connStore.connMap[conn.GetID()]; ok && stored == conn {
+		delete(connStore.connMap, conn.GetID())
+	}
+}
+
+// updateConnectionID 는 key rotation 으로 ID 가 바뀐 connection 을 새 ID 로 다시 등록한다.
+func (connStore *ConnectionStore) updateConnectionID(conn Connection, oldID ConnID) {
+	connStore.Lock()
+	defer connStore.Unlock()
+
+	// 이미 삭제되었거나 다른 connection 으로 바뀐 경우
+	if stored, ok := connStore.connMap[oldID]; !ok || stored != conn {
+		return
+	}
+
+	delete(connStore.connMap, oldID)
+	connStore.connMap[conn.GetID()] = conn
+}
+
+func (connStore *ConnectionStore) DeleteConnection(connID ConnID) error {
+	connStore.Lock()
+
 	conn, err := connStore.GetConnection(connID)
 
 	if conn == nil {
+		connStore.Unlock()
 		return err
 	}
 
-	conn.Close()
 	delete(connStore.connMap, connID)
+	connStore.Unlock()
+
+	// 끊긴 connection 을 삭제하는 listener 가 store 의 lock 을 잡으므로 lock 밖에서 끊는다.
+	conn.Close()
 
 	return nil
 }
 
-func (connStore ConnectionStore) GetConnection(connID ConnID) (Connection, error) {
+func (connStore *ConnectionStore) GetConnection(connID ConnID) (Connection, error) {
 
 	conn, ok := connStore.connMap[connID]
 
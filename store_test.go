@@ -44,3 +44,17 @@ func TestConnectionStore_GetConnection(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, testConn, loadedConn)
 }
+
+func TestConnectionStore_whenConnectionClosed(t *testing.T) {
+	testConnStore := bifrost.NewConnectionStore()
+	testConn, err := mocks.NewMockConnection("127.0.0.1:1234")
+	assert.NoError(t, err)
+
+	err = testConnStore.AddConnection(testConn)
+	assert.NoError(t, err)
+
+	testConn.Close()
+
+	_, err = testConnStore.GetConnection(testConn.GetID())
+	assert.Equal(t, bifrost.ErrConnNotExist, err)
+}
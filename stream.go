@@ -16,6 +16,7 @@ type StreamWrapper interface {
 	Stream
 	Close()
 	GetStream() Stream
+	Context() context.Context
 }
 
 type CStreamWrapper struct {
@@ -56,6 +57,10 @@ func (csw *CStreamWrapper) Recv() (*pb.Envelope, error) {
 	return csw.clientStream.Recv()
 }
 
+func (csw *CStreamWrapper) Context() context.Context {
+	return csw.clientStream.Context()
+}
+
 func (csw *CStreamWrapper) Close() {
 	csw.conn.Close()
 	csw.clientStream.CloseSend()
@@ -79,6 +84,10 @@ func (ssw *SStreamWrapper) GetStream() Stream {
 	return ssw.serverStream
 }
 
+func (ssw *SStreamWrapper) Context() context.Context {
+	return ssw.serverStream.Context()
+}
+
 func (ssw *SStreamWrapper) Close() {
 	ssw.cancel()
 }
@@ -0,0 +1,45 @@
+package bifrost
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+var ErrNoPeerCertificate = errors.New("no tls peer certificate")
+var ErrCertKeyMismatch = errors.New("tls peer certificate key does not match peer key")
+
+// VerifyPeerCertificateKey 는 TLS 연결에서 상대방 인증서의 public key 가 handshake 에서 받은 peerKey 와 같은지 확인한다.
+// ctx 는 grpc stream 의 context 이며, peerKey.ToByte() 는 PKIX 형식의 public key 를 반환해야 한다.
+func VerifyPeerCertificateKey(ctx context.Context, peerKey Key) error {
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ErrNoPeerCertificate
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return ErrNoPeerCertificate
+	}
+
+	certKey, err := x509.MarshalPKIXPublicKey(tlsInfo.State.PeerCertificates[0].PublicKey)
+	if err != nil {
+		return err
+	}
+
+	keyBytes, err := peerKey.ToByte()
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(certKey, keyBytes) {
+		return ErrCertKeyMismatch
+	}
+
+	return nil
+}
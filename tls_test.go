@@ -0,0 +1,65 @@
+package bifrost_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/DE-labtory/bifrost/mocks"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func newTLSPeerContext(t *testing.T, priKey bifrost.Key) context.Context {
+	ca, err := mocks.NewMockCA()
+	assert.NoError(t, err)
+
+	cert, err := ca.Issue(priKey)
+	assert.NoError(t, err)
+
+	x509Cert, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+
+	authInfo := credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{x509Cert}}}
+
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: authInfo})
+}
+
+func TestVerifyPeerCertificateKey(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	ctx := newTLSPeerContext(t, keyOpts.PriKey)
+
+	// when
+	err := bifrost.VerifyPeerCertificateKey(ctx, keyOpts.PubKey)
+
+	// then
+	assert.NoError(t, err)
+}
+
+func TestVerifyPeerCertificateKey_whenKeyMismatch(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+	otherKeyOpts := mocks.NewMockKeyOpts()
+	ctx := newTLSPeerContext(t, otherKeyOpts.PriKey)
+
+	// when
+	err := bifrost.VerifyPeerCertificateKey(ctx, keyOpts.PubKey)
+
+	// then
+	assert.Equal(t, bifrost.ErrCertKeyMismatch, err)
+}
+
+func TestVerifyPeerCertificateKey_whenNotTLS(t *testing.T) {
+	// given
+	keyOpts := mocks.NewMockKeyOpts()
+
+	// when
+	err := bifrost.VerifyPeerCertificateKey(context.Background(), keyOpts.PubKey)
+
+	// then
+	assert.Equal(t, bifrost.ErrNoPeerCertificate, err)
+}
@@ -0,0 +1,494 @@
+package bifrost
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/DE-labtory/bifrost/pb"
+	"github.com/golang/protobuf/proto"
+)
+
+// 상대방이 전송을 중단한 경우 발생하는 에러. 중단 사유가 함께 담긴다.
+var ErrStreamAborted = errors.New("stream aborted")
+
+// 받은 chunk 의 순서나 digest 가 맞지 않는 경우 발생하는 에러
+var ErrStreamCorrupted = errors.New("stream corrupted")
+
+// 닫은 StreamReader 를 읽는 경우 발생하는 에러
+var ErrStreamClosed = errors.New("stream closed")
+
+// 상대방이 동시에 보내는 stream 이 제한보다 많은 경우 발생하는 에러
+var ErrTooManyStreams = errors.New("too many incoming streams")
+
+const (
+	// gRPC 의 기본 메세지 크기 제한(4MB) 보다 작아야 한다.
+	defaultChunkSize    = 1024 * 1024
+	defaultStreamWindow = 8
+	// 받는 쪽이 transfer 하나에 buffer 로 허용하는 최대 chunk 수
+	maxStreamWindow           = 64
+	defaultMaxIncomingStreams = 16
+)
+
+// outgoingTransfer 는 SendStream 으로 보내는 중인 transfer 이다.
+type outgoingTransfer struct {
+	// 상대방이 읽어서 더 보낼 수 있는 chunk 수
+	credits chan struct{}
+	once    sync.Once
+	done    chan error
+}
+
+func (t *outgoingTransfer) finish(err error) {
+	t.once.Do(func() {
+		t.done <- err
+	})
+}
+
+// transfers 는 connection 에서 주고받는 중인 transfer 를 transfer ID 별로 관리한다.
+// 보내는 transfer 와 받는 transfer 는 ID 를 따로 발급하므로 별도의 map 을 사용한다.
+type transfers struct {
+	sync.Mutex
+	outgoing map[uint64]*outgoingTransfer
+	incoming map[uint64]*StreamReader
+	closed   bool
+	// handler 가 아직 다 읽지 않은 받는 transfer 수. 모든 chunk 를 받아 incoming 에서 빠진 transfer 도 포함한다.
+	active      int
+	maxIncoming int
+}
+
+func newTransfers(maxIncoming int) *transfers {
+
+	if maxIncoming <= 0 {
+		maxIncoming = defaultMaxIncomingStreams
+	}
+
+	return &transfers{
+		outgoing:    make(map[uint64]*outgoingTransfer),
+		incoming:    make(map[uint64]*StreamReader),
+		maxIncoming: maxIncoming,
+	}
+}
+
+func (t *transfers) addOutgoing(transferID uint64, window int) (*outgoingTransfer, error) {
+
+	t.Lock()
+	defer t.Unlock()
+
+	if t.closed {
+		return nil, ErrConnClosed
+	}
+
+	transfer := &outgoingTransfer{
+		credits: make(chan struct{}, window),
+		done:    make(chan error, 1),
+	}
+
+	for i := 0; i < window; i++ {
+		transfer.credits <- struct{}{}
+	}
+
+	t.outgoing[transferID] = transfer
+
+	return transfer, nil
+}
+
+func (t *transfers) getOutgoing(transferID uint64) (*outgoingTransfer, bool) {
+
+	t.Lock()
+	defer t.Unlock()
+
+	transfer, ok := t.outgoing[transferID]
+
+	return transfer, ok
+}
+
+func (t *transfers) removeOutgoing(transferID uint64) {
+
+	t.Lock()
+	defer t.Unlock()
+
+	delete(t.outgoing, transferID)
+}
+
+func (t *transfers) addIncoming(reader *StreamReader) error {
+
+	t.Lock()
+	defer t.Unlock()
+
+	if t.closed {
+		return ErrConnClosed
+	}
+
+	if t.active >= t.maxIncoming {
+		return ErrTooManyStreams
+	}
+
+	t.active++
+	t.incoming[reader.transferID] = reader
+
+	return nil
+}
+
+// release 는 handler 가 다 읽었거나 중단된 transfer 를 동시에 받는 transfer 수에서 뺀다.
+func (t *transfers) release() {
+
+	t.Lock()
+	defer t.Unlock()
+
+	t.active--
+}
+
+func (t *transfers) getIncoming(transferID uint64) (*StreamReader, bool) {
+
+	t.Lock()
+	defer t.Unlock()
+
+	reader, ok := t.incoming[transferID]
+
+	return reader, ok
+}
+
+func (t *transfers) removeIncoming(transferID uint64) {
+
+	t.Lock()
+	defer t.Unlock()
+
+	delete(t.incoming, transferID)
+}
+
+// closeAll 은 주고받는 중인 모든 transfer 를 err 로 끝내고, 이후의 transfer 는 받지 않는다.
+func (t *transfers) closeAll(err error) {
+
+	t.Lock()
+
+	t.closed = true
+
+	outgoing := t.outgoing
+	incoming := t.incoming
+	t.outgoing = make(map[uint64]*outgoingTransfer)
+	t.incoming = make(map[uint64]*StreamReader)
+
+	t.Unlock()
+
+	for _, transfer := range outgoing {
+		transfer.finish(err)
+	}
+
+	for _, reader := range incoming {
+		reader.fail(err)
+	}
+}
+
+// StreamReader 는 상대방이 SendStream 으로 보낸 payload 를 읽는다.
+// chunk 를 읽을 때마다 상대방에게 알려서 상대방이 다음 chunk 를 보낼 수 있게 한다.
+type StreamReader struct {
+	conn       *GrpcConnection
+	transferID uint64
+	chunks     chan *pb.Chunk
+	once       sync.Once
+	failed     chan struct{}
+	err        error
+	released   sync.Once
+
+	// chunk 를 받는 Start 의 read loop 만 사용한다.
+	next   uint64
+	digest hash.Hash
+
+	// Read 만 사용한다.
+	current []byte
+	eof     bool
+}
+
+func newStreamReader(conn *GrpcConnection, transferID uint64, window int) *StreamReader {
+	return &StreamReader{
+		conn:       conn,
+		transferID: transferID,
+		chunks:     make(chan *pb.Chunk, window),
+		failed:     make(chan struct{}),
+		digest:     sha256.New(),
+	}
+}
+
+func (r *StreamReader) Read(p []byte) (int, error) {
+
+	for len(r.current) == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+
+		select {
+		case chunk := <-r.chunks:
+			r.current = chunk.Data
+			r.eof = chunk.Last
+			if r.eof {
+				// 보내는 쪽이 완료를 확인하기 전에 자리를 비워야 바로 다음 stream 을 받을 수 있다.
+				r.release()
+			}
+			r.conn.sendChunkAck(&pb.ChunkAck{TransferId: r.transferID, Index: chunk.Index, Done: chunk.Last})
+		case <-r.failed:
+			return 0, r.err
+		}
+	}
+
+	n := copy(p, r.current)
+	r.current = r.current[n:]
+
+	return n, nil
+}
+
+// Close 는 끝까지 읽지 않은 transfer 를 중단하고 상대방에게 알린다.
+func (r *StreamReader) Close() error {
+
+	if !r.eof {
+		r.conn.sendChunkAck(&pb.ChunkAck{TransferId: r.transferID, Abort: "closed by receiver"})
+	}
+
+	r.fail(ErrStreamClosed)
+
+	return nil
+}
+
+func (r *StreamReader) fail(err error) {
+	r.once.Do(func() {
+		r.err = err
+		close(r.failed)
+		r.conn.transfers.removeIncoming(r.transferID)
+	})
+
+	r.release()
+}
+
+func (r *StreamReader) release() {
+	r.released.Do(r.conn.transfers.release)
+}
+
+// push 는 받은 chunk 의 순서와 digest 를 확인하고 읽을 수 있게 한다.
+func (r *StreamReader) push(chunk *pb.Chunk) error {
+
+	if chunk.Index != r.next {
+		return fmt.Errorf("%w: expected chunk [%d], got [%d]", ErrStreamCorrupted, r.next, chunk.Index)
+	}
+
+	r.next++
+	r.digest.Write(chunk.Data)
+
+	if chunk.Last && !bytes.Equal(r.digest.Sum(nil), chunk.Digest) {
+		return fmt.Errorf("%w: digest mismatch", ErrStreamCorrupted)
+	}
+
+	select {
+	case r.chunks <- chunk:
+	default:
+		return fmt.Errorf("%w: window exceeded", ErrStreamAborted)
+	}
+
+	// 모든 chunk 를 받았으므로 connection 이 닫혀도 남은 chunk 는 읽을 수 있다.
+	if chunk.Last {
+		r.conn.transfers.removeIncoming(r.transferID)
+	}
+
+	return nil
+}
+
+// SendStream 은 r 을 chunk 로 나누어 보낸다. 상대방 handler 는 Message.Stream 으로 읽는다.
+// 상대방이 읽은 만큼만 보내며(flow control), 상대방이 모든 chunk 를 읽고 digest 를 확인하면 nil 을 반환한다.
+// ctx 가 끝나거나 r 을 읽지 못하면 상대방에게 중단을 알린다.
+func (conn *GrpcConnection) SendStream(ctx context.Context, r io.Reader, protocol string) error {
+
+	transferID := atomic.AddUint64(&conn.transferSeq, 1)
+
+	transfer, err := conn.transfers.addOutgoing(transferID, conn.streamWindow)
+	if err != nil {
+		return err
+	}
+	defer conn.transfers.removeOutgoing(transferID)
+
+	digest := sha256.New()
+
+	for index := uint64(0); ; index++ {
+		buf := make([]byte, conn.chunkSize)
+
+		n, err := io.ReadFull(r, buf)
+		last := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !last {
+			conn.abortTransfer(transferID, protocol, err)
+			return err
+		}
+
+		select {
+		case <-transfer.credits:
+		case err := <-transfer.done:
+			return err
+		case <-ctx.Done():
+			conn.abortTransfer(transferID, protocol, ctx.Err())
+			return ctx.Err()
+		}
+
+		chunk := &pb.Chunk{TransferId: transferID, Index: index, Data: buf[:n], Last: last}
+
+		// 받는 쪽은 첫 chunk 에 담긴 window 만큼 buffer 를 만든다.
+		if index == 0 {
+			chunk.Window = uint32(conn.streamWindow)
+		}
+		digest.Write(chunk.Data)
+
+		if last {
+			chunk.Digest = digest.Sum(nil)
+		}
+
+		// 대기열에 넣기 전에 ctx 가 끝나면 상대방이 나머지 chunk 를 기다리지 않도록 중단을 알린다.
+		if err := conn.sendChunk(ctx, protocol, chunk, transfer); err != nil {
+			conn.abortTransfer(transferID, protocol, err)
+			return err
+		}
+
+		if last {
+			break
+		}
+	}
+
+	select {
+	case err := <-transfer.done:
+		return err
+	case <-ctx.Done():
+		conn.abortTransfer(transferID, protocol, ctx.Err())
+		return ctx.Err()
+	}
+}
+
+func (conn *GrpcConnection) sendChunk(ctx context.Context, protocol string, chunk *pb.Chunk, transfer *outgoingTransfer) error {
+
+	payload, err := proto.Marshal(chunk)
+	if err != nil {
+		return err
+	}
+
+	return conn.enqueueShared(ctx, &pb.Envelope{Type: pb.Envelope_CHUNK, Protocol: protocol, Payload: payload}, nil, transfer.finish)
+}
+
+// abortTransfer 는 보내는 중인 transfer 를 중단했음을 상대방에게 알린다.
+func (conn *GrpcConnection) abortTransfer(transferID uint64, protocol string, reason error) {
+
+	payload, err := proto.Marshal(&pb.Chunk{TransferId: transferID, Abort: reason.Error()})
+	if err != nil {
+		return
+	}
+
+	conn.send(&pb.Envelope{Type: pb.Envelope_CHUNK, Protocol: protocol, Payload: payload}, nil, nil)
+}
+
+func (conn *GrpcConnection) sendChunkAck(ack *pb.ChunkAck) {
+
+	payload, err := proto.Marshal(ack)
+	if err != nil {
+		return
+	}
+
+	conn.send(&pb.Envelope{Type: pb.Envelope_CHUNK_ACK, Payload: payload}, nil, nil)
+}
+
+// receiveChunk 는 상대방이 보낸 chunk 를 transfer 의 StreamReader 에 전달한다.
+// 첫 chunk 를 받으면 handler 가 Stream 을 읽는 동안 다음 chunk 를 받을 수 있도록 별도의 goroutine 에서 handler 를 호출한다.
+func (conn *GrpcConnection) receiveChunk(envelope *pb.Envelope) {
+
+	chunk := &pb.Chunk{}
+	if err := proto.Unmarshal(envelope.Payload, chunk); err != nil {
+		conn.serveError(err)
+		conn.report(EventMalformedMessage)
+		return
+	}
+
+	if chunk.Abort != "" {
+		if reader, ok := conn.transfers.getIncoming(chunk.TransferId); ok {
+			reader.fail(fmt.Errorf("%w: %s", ErrStreamAborted, chunk.Abort))
+		}
+		return
+	}
+
+	reader, ok := conn.transfers.getIncoming(chunk.TransferId)
+	if !ok {
+		// 이미 끝났거나 중단한 transfer 의 chunk 는 버린다.
+		if chunk.Index != 0 {
+			return
+		}
+
+		window, err := incomingWindow(chunk)
+		if err != nil {
+			conn.sendChunkAck(&pb.ChunkAck{TransferId: chunk.TransferId, Abort: err.Error()})
+			conn.serveError(err)
+			conn.report(EventProtocolViolation)
+			return
+		}
+
+		reader = newStreamReader(conn, chunk.TransferId, window)
+		if err := conn.transfers.addIncoming(reader); err != nil {
+			if err == ErrTooManyStreams {
+				conn.sendChunkAck(&pb.ChunkAck{TransferId: chunk.TransferId, Abort: err.Error()})
+				conn.serveError(err)
+			}
+			return
+		}
+
+		go conn.handler.ServeRequest(Message{Envelope: envelope, Conn: conn, Origin: conn.GetPeerKey(), Stream: reader})
+	}
+
+	if err := reader.push(chunk); err != nil {
+		reader.fail(err)
+		conn.sendChunkAck(&pb.ChunkAck{TransferId: chunk.TransferId, Abort: err.Error()})
+		conn.serveError(err)
+		conn.report(EventProtocolViolation)
+	}
+}
+
+// incomingWindow 는 첫 chunk 에 담긴 보내는 쪽의 window 를 확인한다.
+// window 가 없으면 이전 version 의 기본 window 를 사용한다.
+func incomingWindow(chunk *pb.Chunk) (int, error) {
+
+	if chunk.Window == 0 {
+		return defaultStreamWindow, nil
+	}
+
+	if chunk.Window > maxStreamWindow {
+		return 0, fmt.Errorf("%w: window [%d] exceeds [%d]", ErrStreamAborted, chunk.Window, maxStreamWindow)
+	}
+
+	return int(chunk.Window), nil
+}
+
+// receiveChunkAck 는 상대방이 chunk 를 읽었음을 보내는 중인 transfer 에 알린다.
+func (conn *GrpcConnection) receiveChunkAck(envelope *pb.Envelope) {
+
+	ack := &pb.ChunkAck{}
+	if err := proto.Unmarshal(envelope.Payload, ack); err != nil {
+		conn.serveError(err)
+		conn.report(EventMalformedMessage)
+		return
+	}
+
+	transfer, ok := conn.transfers.getOutgoing(ack.TransferId)
+	if !ok {
+		return
+	}
+
+	if ack.Abort != "" {
+		transfer.finish(fmt.Errorf("%w: %s", ErrStreamAborted, ack.Abort))
+		return
+	}
+
+	if ack.Done {
+		transfer.finish(nil)
+		return
+	}
+
+	// window 보다 많이 돌려받은 credit 은 버린다.
+	select {
+	case transfer.credits <- struct{}{}:
+	default:
+	}
+}
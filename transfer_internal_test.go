@@ -0,0 +1,49 @@
+package bifrost
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/DE-labtory/bifrost/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamReader_push_whenChunkOutOfOrder(t *testing.T) {
+	// given
+	reader := newStreamReader(&GrpcConnection{transfers: newTransfers(0)}, 1, 4)
+
+	// when
+	err := reader.push(&pb.Chunk{TransferId: 1, Index: 1, Data: []byte("hello")})
+
+	// then
+	assert.True(t, errors.Is(err, ErrStreamCorrupted))
+}
+
+func TestStreamReader_push_whenDigestMismatch(t *testing.T) {
+	// given
+	reader := newStreamReader(&GrpcConnection{transfers: newTransfers(0)}, 1, 4)
+	digest := sha256.Sum256([]byte("hello world"))
+
+	assert.NoError(t, reader.push(&pb.Chunk{TransferId: 1, Index: 0, Data: []byte("hello")}))
+
+	// when
+	err := reader.push(&pb.Chunk{TransferId: 1, Index: 1, Data: []byte(" there"), Last: true, Digest: digest[:]})
+
+	// then
+	assert.True(t, errors.Is(err, ErrStreamCorrupted))
+}
+
+func TestIncomingWindow(t *testing.T) {
+	// when
+	window, err := incomingWindow(&pb.Chunk{Window: 16})
+	defaultWindow, defaultErr := incomingWindow(&pb.Chunk{})
+	_, tooLargeErr := incomingWindow(&pb.Chunk{Window: maxStreamWindow + 1})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 16, window)
+	assert.NoError(t, defaultErr)
+	assert.Equal(t, defaultStreamWindow, defaultWindow)
+	assert.True(t, errors.Is(tooLargeErr, ErrStreamAborted))
+}
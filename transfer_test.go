@@ -0,0 +1,180 @@
+package bifrost_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/bifrost"
+	"github.com/DE-labtory/bifrost/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrpcConnection_SendStream(t *testing.T) {
+	// given
+	opts := bifrost.ConnOpts{ChunkSize: 16, StreamWindow: 2}
+	localConn, remoteConn := newTestConnPair(t, opts)
+
+	data := make([]byte, 1000)
+	rand.Read(data)
+
+	type result struct {
+		protocol string
+		data     []byte
+		err      error
+	}
+	received := make(chan result, 1)
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		read, err := ioutil.ReadAll(message.Stream)
+		received <- result{protocol: message.Envelope.Protocol, data: read, err: err}
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// when
+	err := localConn.SendStream(context.Background(), bytes.NewReader(data), "block")
+
+	// then
+	assert.NoError(t, err)
+
+	r := <-received
+	assert.NoError(t, r.err)
+	assert.Equal(t, "block", r.protocol)
+	assert.Equal(t, data, r.data)
+}
+
+func TestGrpcConnection_SendStream_whenWindowsDiffer(t *testing.T) {
+	// given
+	// 받는 쪽은 기본 window(8) 를 사용한다.
+	localConn, remoteConn := newTestConnPairWithOpts(t, bifrost.ConnOpts{ChunkSize: 16, StreamWindow: 16}, bifrost.ConnOpts{}, nil)
+
+	data := make([]byte, 1000)
+	rand.Read(data)
+
+	proceed := make(chan struct{})
+	received := make(chan []byte, 1)
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		// 보내는 쪽이 window 만큼 chunk 를 먼저 보낼 때까지 읽지 않는다.
+		<-proceed
+		read, err := ioutil.ReadAll(message.Stream)
+		assert.NoError(t, err)
+		received <- read
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	sendErr := make(chan error, 1)
+
+	// when
+	go func() {
+		sendErr <- localConn.SendStream(context.Background(), bytes.NewReader(data), "block")
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(proceed)
+
+	// then
+	assert.NoError(t, <-sendErr)
+	assert.Equal(t, data, <-received)
+}
+
+func TestGrpcConnection_SendStream_whenReceiverClosed(t *testing.T) {
+	// given
+	opts := bifrost.ConnOpts{ChunkSize: 16, StreamWindow: 2}
+	localConn, remoteConn := newTestConnPair(t, opts)
+
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		message.Stream.Read(make([]byte, 4))
+		message.Stream.Close()
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	// when
+	err := localConn.SendStream(context.Background(), bytes.NewReader(make([]byte, 1000)), "block")
+
+	// then
+	assert.True(t, errors.Is(err, bifrost.ErrStreamAborted))
+}
+
+func TestGrpcConnection_SendStream_whenTooManyStreams(t *testing.T) {
+	// given
+	localConn, remoteConn := newTestConnPairWithOpts(t, bifrost.ConnOpts{ChunkSize: 16, StreamWindow: 2}, bifrost.ConnOpts{MaxIncomingStreams: 1}, nil)
+
+	started := make(chan struct{}, 1)
+	proceed := make(chan struct{})
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		started <- struct{}{}
+		<-proceed
+		_, err := ioutil.ReadAll(message.Stream)
+		assert.NoError(t, err)
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	firstErr := make(chan error, 1)
+	go func() {
+		firstErr <- localConn.SendStream(context.Background(), bytes.NewReader(make([]byte, 1000)), "block")
+	}()
+	<-started
+
+	// when
+	err := localConn.SendStream(context.Background(), bytes.NewReader(make([]byte, 1000)), "block")
+
+	// then
+	assert.True(t, errors.Is(err, bifrost.ErrStreamAborted))
+
+	close(proceed)
+	assert.NoError(t, <-firstErr)
+
+	// 다 읽은 stream 의 자리는 다음 stream 이 사용할 수 있다.
+	assert.NoError(t, localConn.SendStream(context.Background(), bytes.NewReader(make([]byte, 1000)), "block"))
+}
+
+func TestGrpcConnection_SendStream_whenSenderCanceled(t *testing.T) {
+	// given
+	opts := bifrost.ConnOpts{ChunkSize: 16, StreamWindow: 2}
+	localConn, remoteConn := newTestConnPair(t, opts)
+
+	started := make(chan struct{})
+	proceed := make(chan struct{})
+	readErr := make(chan error, 1)
+	remoteConn.Handle(mocks.MockFuncHandler{RequestFunc: func(message bifrost.Message) {
+		close(started)
+		<-proceed
+		_, err := ioutil.ReadAll(message.Stream)
+		readErr <- err
+	}})
+
+	go localConn.Start()
+	go remoteConn.Start()
+	defer localConn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sendErr := make(chan error, 1)
+	go func() {
+		sendErr <- localConn.SendStream(ctx, bytes.NewReader(make([]byte, 1000)), "block")
+	}()
+	<-started
+
+	// when
+	cancel()
+
+	// then
+	assert.Equal(t, context.Canceled, <-sendErr)
+
+	close(proceed)
+	assert.True(t, errors.Is(<-readErr, bifrost.ErrStreamAborted))
+}
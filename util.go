@@ -1,39 +1,37 @@
 package bifrost
 
 import (
-	"context"
+	"crypto/rand"
 	"encoding/json"
-	"time"
+	"errors"
 
 	"github.com/DE-labtory/bifrost/pb"
 )
 
-func RecvWithTimeout(timeout time.Duration, stream Stream) (*pb.Envelope, error) {
+// handshake challenge 에 사용되는 nonce 의 크기
+const NonceSize = 32
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+// handshake 과정에서 상대방의 서명이 자신이 보낸 nonce 에 대해 유효하지 않을 경우 발생하는 에러
+var ErrInvalidPeerSignature = errors.New("invalid peer signature")
 
-	c := make(chan *pb.Envelope, 1)
-	errch := make(chan error, 1)
+// handshake 과정에서 nonce 의 형식이 올바르지 않을 경우 발생하는 에러
+var ErrInvalidNonce = errors.New("invalid nonce")
 
-	go func() {
-		envelope, err := stream.Recv()
-		if err != nil {
-			errch <- err
-		}
-		c <- envelope
-	}()
+// handshake 과정에서 상대방이 연결을 거절한 경우 발생하는 에러. 거절 사유는 PeerRejectedError 에 담긴다.
+var ErrPeerRejected = errors.New("peer rejected")
 
-	select {
-	case <-ctx.Done():
-		//timeoutted body
-		return nil, ctx.Err()
-	case err := <-errch:
-		return nil, err
-	case ok := <-c:
-		//okay body
-		return ok, nil
-	}
+// PeerRejectedError 는 상대방이 알려준 거절 사유를 담는다.
+// errors.Is(err, ErrPeerRejected) 로 확인할 수 있다.
+type PeerRejectedError struct {
+	Reason string
+}
+
+func (e *PeerRejectedError) Error() string {
+	return ErrPeerRejected.Error() + ": " + e.Reason
+}
+
+func (e *PeerRejectedError) Is(target error) bool {
+	return target == ErrPeerRejected
 }
 
 type KeyOpts struct {
@@ -41,28 +39,104 @@ type KeyOpts struct {
 	PubKey Key
 }
 
-func BuildResponsePeerInfo(ip string, pubKey Key, metaData map[string]string) (*pb.Envelope, error) {
+// NewNonce 는 handshake challenge 에 사용할 random nonce 를 생성한다.
+func NewNonce() ([]byte, error) {
+	nonce := make([]byte, NonceSize)
+
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return nonce, nil
+}
+
+// BuildRequestPeerInfo 는 상대방에게 peer info 와 nonce 에 대한 서명을 요청하는 envelope 을 만든다.
+func BuildRequestPeerInfo(nonce []byte) *pb.Envelope {
+	return &pb.Envelope{
+		Payload: nonce,
+		Type:    pb.Envelope_REQUEST_PEERINFO,
+	}
+}
+
+// NewPeerInfo 는 handshake 에서 상대방에게 보낼 자신의 peer info 를 만든다.
+func NewPeerInfo(ip string, pubKey Key, metaData map[string]string) (*PeerInfo, error) {
 	b, err := pubKey.ToByte()
 
 	if err != nil {
 		return nil, err
 	}
 
-	pi := &PeerInfo{
+	return &PeerInfo{
 		IP:          ip,
 		PubKeyBytes: b,
 		IsPrivate:   pubKey.IsPrivate(),
 		MetaData:    metaData,
+		Version:     ProtocolVersion,
+		Features:    FeatureSet{},
+	}, nil
+}
+
+// BuildResponsePeerInfo 는 자신의 peer info 를 담고, 상대방이 보낸 nonce(peerNonce) 와 payload 에 서명한 envelope 을 만든다.
+func BuildResponsePeerInfo(peerInfo *PeerInfo, peerNonce []byte, signer Signer) (*pb.Envelope, error) {
+
+	payload, err := json.Marshal(peerInfo)
+
+	if err != nil {
+		return nil, err
 	}
 
-	payload, err := json.Marshal(pi)
+	sig, err := signer.Sign(challengeMessage(peerNonce, payload))
 
 	if err != nil {
 		return nil, err
 	}
 
 	return &pb.Envelope{
-		Payload: payload,
-		Type:    pb.Envelope_RESPONSE_PEERINFO,
+		Payload:   payload,
+		Signature: sig,
+		Type:      pb.Envelope_RESPONSE_PEERINFO,
 	}, nil
 }
+
+// BuildRejectPeer 는 상대방에게 연결 거절 사유를 알리는 envelope 을 만든다.
+func BuildRejectPeer(reason string) *pb.Envelope {
+	return &pb.Envelope{
+		Payload: []byte(reason),
+		Type:    pb.Envelope_REJECT_PEER,
+	}
+}
+
+// VerifyResponsePeerInfo 는 상대방의 peer info 에서 key 를 복구하고, 자신이 보낸 nonce 에 대한 서명을 검증한다.
+// 서명이 유효하지 않을 경우 ErrInvalidPeerSignature 를 반환한다.
+func VerifyResponsePeerInfo(envelope *pb.Envelope, nonce []byte, crypto Crypto) (Key, *PeerInfo, error) {
+
+	peerInfo := &PeerInfo{}
+
+	if err := json.Unmarshal(envelope.Payload, peerInfo); err != nil {
+		return nil, nil, err
+	}
+
+	peerKey, err := crypto.RecoverKeyFromByte(peerInfo.PubKeyBytes, peerInfo.IsPrivate)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	valid, err := crypto.Verify(peerKey, envelope.Signature, challengeMessage(nonce, envelope.Payload))
+
+	if err != nil || !valid {
+		return nil, nil, ErrInvalidPeerSignature
+	}
+
+	return peerKey, peerInfo, nil
+}
+
+// 서명 대상은 상대방의 nonce 와 자신의 peer info 를 이어붙인 값이다.
+// peer info 까지 서명해야 다른 peer 의 서명을 가져와 자신의 key 로 바꿔치기할 수 없다.
+func challengeMessage(nonce []byte, payload []byte) []byte {
+	message := make([]byte, 0, len(nonce)+len(payload))
+	message = append(message, nonce...)
+	message = append(message, payload...)
+
+	return message
+}
@@ -13,12 +13,76 @@ func TestBuildResponsePeerInfo(t *testing.T) {
 	//given
 	ip := "127.0.0.1:2323"
 	keyOpt := mocks.NewMockKeyOpts()
+	crypto := mocks.NewMockCryptoWithKey(keyOpt.PriKey)
+	peerNonce, err := bifrost.NewNonce()
+	assert.NoError(t, err)
+
+	peerInfo, err := bifrost.NewPeerInfo(ip, keyOpt.PubKey, nil)
+	assert.NoError(t, err)
 
 	//when
-	envelope, err := bifrost.BuildResponsePeerInfo(ip, keyOpt.PubKey, nil)
+	envelope, err := bifrost.BuildResponsePeerInfo(peerInfo, peerNonce, crypto.Signer)
 	assert.NoError(t, err)
 
 	//then
 	assert.NoError(t, err)
 	assert.Equal(t, envelope.Type, pb.Envelope_RESPONSE_PEERINFO)
+	assert.NotNil(t, envelope.Signature)
+}
+
+func TestVerifyResponsePeerInfo(t *testing.T) {
+	//given
+	ip := "127.0.0.1:2323"
+	keyOpt := mocks.NewMockKeyOpts()
+	crypto := mocks.NewMockCryptoWithKey(keyOpt.PriKey)
+	nonce, err := bifrost.NewNonce()
+	assert.NoError(t, err)
+	peerNonce, err := bifrost.NewNonce()
+	assert.NoError(t, err)
+
+	peerInfo, err := bifrost.NewPeerInfo(ip, keyOpt.PubKey, nil)
+	assert.NoError(t, err)
+	peerInfo.Nonce = peerNonce
+
+	envelope, err := bifrost.BuildResponsePeerInfo(peerInfo, nonce, crypto.Signer)
+	assert.NoError(t, err)
+
+	//when
+	peerKey, verifiedInfo, err := bifrost.VerifyResponsePeerInfo(envelope, nonce, crypto)
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, keyOpt.PubKey.ID(), peerKey.ID())
+	assert.Equal(t, ip, verifiedInfo.IP)
+	assert.Equal(t, peerNonce, verifiedInfo.Nonce)
+}
+
+func TestVerifyResponsePeerInfo_whenInvalidSignature(t *testing.T) {
+	//given
+	ip := "127.0.0.1:2323"
+	keyOpt := mocks.NewMockKeyOpts()
+	otherKeyOpt := mocks.NewMockKeyOpts()
+	nonce, err := bifrost.NewNonce()
+	assert.NoError(t, err)
+	otherNonce, err := bifrost.NewNonce()
+	assert.NoError(t, err)
+
+	peerInfo, err := bifrost.NewPeerInfo(ip, keyOpt.PubKey, nil)
+	assert.NoError(t, err)
+
+	// 다른 nonce 에 서명
+	envelope, err := bifrost.BuildResponsePeerInfo(peerInfo, otherNonce, mocks.NewMockCryptoWithKey(keyOpt.PriKey).Signer)
+	assert.NoError(t, err)
+
+	// 다른 key 로 서명
+	forgedEnvelope, err := bifrost.BuildResponsePeerInfo(peerInfo, nonce, mocks.NewMockCryptoWithKey(otherKeyOpt.PriKey).Signer)
+	assert.NoError(t, err)
+
+	//when
+	_, _, err = bifrost.VerifyResponsePeerInfo(envelope, nonce, mocks.NewMockCrypto())
+	_, _, forgedErr := bifrost.VerifyResponsePeerInfo(forgedEnvelope, nonce, mocks.NewMockCrypto())
+
+	//then
+	assert.Equal(t, bifrost.ErrInvalidPeerSignature, err)
+	assert.Equal(t, bifrost.ErrInvalidPeerSignature, forgedErr)
 }